@@ -16,6 +16,8 @@ import (
 
 func main() {
 
+	config.Init()
+
 	info.Banner()
 
 	cfg := config.Base()
@@ -44,8 +46,8 @@ func main() {
 	server.Start()
 
 	shutdown.Register(server.Close)       //   ↓↓
-	shutdown.Register(database.Close)     //   ↓↓
 	shutdown.Register(node.CloseNodePool) //   ↓↓
+	shutdown.Register(database.Close)     //   ↓↓
 	shutdown.Register(log.Close)          //   ↓↓
 
 	shutdown.Listen()
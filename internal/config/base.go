@@ -18,7 +18,8 @@ import (
 
 var baseConfig = config.DefaultBase()
 
-func init() {
+// Init 解析命令行参数并加载配置文件, 文件不存在时生成默认配置, 需在读取配置前调用
+func Init() {
 	execPath, err := os.Executable()
 	if err != nil {
 		panic(fmt.Errorf("获取可执行文件路径失败: %v", err))
@@ -80,6 +81,12 @@ func setupPaths(config *config.Base, configPath string) {
 		config.Log.Path = filepath.Join(configDir, "log")
 	}
 
+	if config.Sub.FileDir == "" {
+		config.Sub.FileDir = filepath.Join(configDir, "subs")
+	} else if !filepath.IsAbs(config.Sub.FileDir) {
+		config.Sub.FileDir = filepath.Join(configDir, config.Sub.FileDir)
+	}
+
 	if config.Session.NodePath == "" {
 		config.Session.NodePath = filepath.Join(configDir, "session", "node.session")
 	}
@@ -125,6 +132,9 @@ func loadFromEnv(config *config.Base) {
 	if logDir := os.Getenv("BESTSUB_LOG_DIR"); logDir != "" {
 		config.Log.Path = logDir
 	}
+	if fileDir := os.Getenv("BESTSUB_SUB_FILE_DIR"); fileDir != "" {
+		config.Sub.FileDir = fileDir
+	}
 	if jwtSecret := os.Getenv("BESTSUB_JWT_SECRET"); jwtSecret != "" {
 		config.JWT.Secret = jwtSecret
 	}
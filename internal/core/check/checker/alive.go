@@ -79,23 +79,30 @@ func (e *Alive) Run(ctx context.Context, log *log.Logger, subID []uint16) checkM
 			}
 			start := time.Now()
 			alive := e.detect(ctx, raw)
+			delay := uint16(65535)
+			if alive {
+				delay = uint16(time.Since(start).Milliseconds())
+			}
+			var average uint16
+			node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+				node.RecordAlive(info, alive)
+				info.Delay.Update(delay)
+				average = info.Delay.Average()
+			})
 			if alive {
 				log.Debugf("Node %s is alive ✔", raw["name"].(string))
 				atomic.AddInt64(&aliveCount, 1)
-				n.Info.SetAliveStatus(nodeModel.Alive, true)
-				n.Info.Delay.Update(uint16(time.Since(start).Milliseconds()))
-				log.Debugf("Node %s delay: %dms", raw["name"].(string), n.Info.Delay.Average())
-				atomic.AddInt64(&totalDelay, int64(n.Info.Delay.Average()))
+				log.Debugf("Node %s delay: %dms", raw["name"].(string), average)
+				atomic.AddInt64(&totalDelay, int64(average))
 			} else {
 				log.Debugf("Node %s is dead ✘", raw["name"].(string))
 				atomic.AddInt64(&deadCount, 1)
-				n.Info.SetAliveStatus(nodeModel.Alive, false)
-				n.Info.Delay.Update(uint16(65535))
 			}
 
 		})
 	}
 	wg.Wait()
+	node.AliveRunDone(int(aliveCount), int(deadCount))
 	avgDelay := int64(0)
 	if aliveCount > 0 {
 		avgDelay = totalDelay / aliveCount
@@ -77,12 +77,12 @@ func (e *Country) Run(ctx context.Context, log *log.Logger, subID []uint16) chec
 			client.Timeout = time.Duration(e.Timeout) * time.Second
 			defer client.Release()
 			countryCode := country.GetCode(ctx, client.Client)
-			if countryCode != "" {
-				n.Info.Country = countryCode
-				n.Info.SetAliveStatus(nodeModel.Country, true)
-			} else {
-				n.Info.SetAliveStatus(nodeModel.Country, false)
-			}
+			node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+				if countryCode != "" {
+					info.Country = countryCode
+				}
+				info.SetAliveStatus(nodeModel.Country, countryCode != "")
+			})
 		})
 	}
 	wg.Wait()
@@ -0,0 +1,289 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bestruirui/bestsub/internal/core/mihomo"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/core/task"
+	checkModel "github.com/bestruirui/bestsub/internal/models/check"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/modules/register"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/bestruirui/bestsub/internal/utils/ua"
+)
+
+// probeBodyLimit 单次探测最多读取的响应体大小
+const probeBodyLimit = 1 << 20
+
+// Probe 声明式的 HTTP 探测, 每条规则命中时为节点设置对应的能力
+// 同一能力可配置多条规则, 任一规则命中即视为具备该能力
+// 检测后节点的能力替换为本次规则命中的集合
+type Probe struct {
+	Thread  int    `json:"thread" name:"线程数" value:"100"`
+	Timeout int    `json:"timeout" name:"超时时间" value:"10" desc:"单个探测请求的超时时间(s)"`
+	Probes  string `json:"probes" name:"探测规则" value:"[]" desc:"JSON 数组, 每项包含 capability、url, 可选 method、headers、body、status(期望状态码列表, 默认 2xx)、match(响应体正则)、json_path 与 json_match(JSON 字段值正则)"`
+
+	rules []probeRule
+}
+
+type probeRule struct {
+	Capability string            `json:"capability"`
+	URL        string            `json:"url"`
+	Method     string            `json:"method"`
+	Headers    map[string]string `json:"headers"`
+	Body       string            `json:"body"`
+	Status     []int             `json:"status"`
+	Match      string            `json:"match"`
+	JSONPath   string            `json:"json_path"`
+	JSONMatch  string            `json:"json_match"`
+
+	match     *regexp.Regexp
+	jsonMatch *regexp.Regexp
+}
+
+func (e *Probe) Init() error {
+	var rules []probeRule
+	if err := json.Unmarshal([]byte(e.Probes), &rules); err != nil {
+		return fmt.Errorf("invalid probes: %w", err)
+	}
+	for i := range rules {
+		r := &rules[i]
+		if r.Capability == "" || r.URL == "" {
+			return fmt.Errorf("probe %d: capability and url are required", i)
+		}
+		if r.Method == "" {
+			r.Method = http.MethodGet
+		}
+		var err error
+		if r.Match != "" {
+			if r.match, err = regexp.Compile(r.Match); err != nil {
+				return fmt.Errorf("probe %s: %w", r.Capability, err)
+			}
+		}
+		if r.JSONMatch != "" {
+			if r.jsonMatch, err = regexp.Compile(r.JSONMatch); err != nil {
+				return fmt.Errorf("probe %s: %w", r.Capability, err)
+			}
+		}
+	}
+	e.rules = rules
+	return nil
+}
+
+func (e *Probe) Run(ctx context.Context, log *log.Logger, subID []uint16) checkModel.Result {
+	startTime := time.Now()
+	if err := e.Init(); err != nil {
+		log.Warnf("probe check task failed, %v", err)
+		return checkModel.Result{
+			Msg:      err.Error(),
+			LastRun:  time.Now(),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+	var nodes []nodeModel.Data
+	if len(subID) == 0 {
+		nodes = node.GetAll()
+	} else {
+		nodes = *node.GetBySubId(subID)
+	}
+	threads := e.Thread
+	if threads <= 0 || threads > len(nodes) {
+		threads = len(nodes)
+	}
+	if threads > task.MaxThread() {
+		threads = task.MaxThread()
+	}
+	if threads == 0 || len(nodes) == 0 || len(e.rules) == 0 {
+		log.Warnf("probe check task failed, no nodes or probes")
+		return checkModel.Result{
+			Msg:      "no nodes or probes",
+			LastRun:  time.Now(),
+			Duration: time.Since(startTime).Milliseconds(),
+		}
+	}
+	sem := make(chan struct{}, threads)
+	defer close(sem)
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	var wg sync.WaitGroup
+	for _, nd := range nodes {
+		sem <- struct{}{}
+		wg.Add(1)
+		n := nd
+		task.Submit(func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			var raw map[string]any
+			if err := yaml.Unmarshal(n.Raw, &raw); err != nil {
+				log.Warnf("yaml.Unmarshal failed: %v", err)
+				return
+			}
+			client := mihomo.Proxy(raw)
+			if client == nil {
+				return
+			}
+			client.Timeout = time.Duration(e.Timeout) * time.Second
+			defer client.Release()
+
+			result := make(map[string]bool, len(e.rules))
+			for i := range e.rules {
+				r := &e.rules[i]
+				if result[r.Capability] {
+					continue
+				}
+				result[r.Capability] = r.detect(ctx, client.Client)
+			}
+			capabilities := make([]string, 0, len(result))
+			for capability, ok := range result {
+				if ok {
+					capabilities = append(capabilities, capability)
+				}
+			}
+			// 整体替换, 已删除规则对应的能力随之清除
+			node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+				info.SetCapabilities(capabilities)
+			})
+			mu.Lock()
+			for _, capability := range capabilities {
+				counts[capability]++
+			}
+			mu.Unlock()
+			log.Debugf("node %s probe result: %v", raw["name"], result)
+		})
+	}
+	wg.Wait()
+
+	capabilities := make([]string, 0, len(counts))
+	extra := make(map[string]any, len(counts))
+	for capability, count := range counts {
+		capabilities = append(capabilities, fmt.Sprintf("%s: %d", capability, count))
+		extra[capability] = count
+	}
+	slices.Sort(capabilities)
+	log.Debugf("probe check task end, %s", strings.Join(capabilities, ", "))
+	return checkModel.Result{
+		Msg:      fmt.Sprintf("success, %s", strings.Join(capabilities, ", ")),
+		LastRun:  time.Now(),
+		Duration: time.Since(startTime).Milliseconds(),
+		Extra:    extra,
+	}
+}
+
+func (r *probeRule) detect(ctx context.Context, client *http.Client) bool {
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return false
+	}
+	ua.SetHeader(req)
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	if len(r.Status) > 0 {
+		if !slices.Contains(r.Status, resp.StatusCode) {
+			return false
+		}
+	} else if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false
+	}
+	if r.match == nil && r.JSONPath == "" {
+		return true
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, probeBodyLimit))
+	if err != nil {
+		return false
+	}
+	if r.match != nil && !r.match.Match(data) {
+		return false
+	}
+	if r.JSONPath != "" {
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return false
+		}
+		value, ok := jsonPath(doc, r.JSONPath)
+		if !ok || value == nil {
+			return false
+		}
+		s := jsonString(value)
+		if r.jsonMatch != nil {
+			return r.jsonMatch.MatchString(s)
+		}
+		return s != "" && s != "false"
+	}
+	return true
+}
+
+// jsonPath 按 $.a.b[0].c 形式的路径取值
+func jsonPath(doc any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc, true
+	}
+	value := doc
+	for _, part := range strings.Split(strings.ReplaceAll(path, "[", ".["), ".") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "[") && strings.HasSuffix(part, "]") {
+			i, err := strconv.Atoi(part[1 : len(part)-1])
+			list, ok := value.([]any)
+			if err != nil || !ok || i < 0 || i >= len(list) {
+				return nil, false
+			}
+			value = list[i]
+			continue
+		}
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func init() {
+	register.Check(&Probe{})
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -70,7 +71,7 @@ func (e *Speed) Run(ctx context.Context, log *log.Logger, subID []uint16) checkM
 	}
 	sem := make(chan struct{}, threads)
 	defer close(sem)
-	var downloadCount, uploadCount int
+	var downloadCount, uploadCount atomic.Int64
 
 	var wg sync.WaitGroup
 	for _, nd := range nodes {
@@ -93,32 +94,36 @@ func (e *Speed) Run(ctx context.Context, log *log.Logger, subID []uint16) checkM
 			}
 			defer client.Release()
 			client.Timeout = time.Duration(e.Timeout) * time.Second
-			if e.Download && downloadCount < e.DownloadCount && (!e.DownloadSkip || n.Info.SpeedDown.Average() == 0) {
+			if e.Download && downloadCount.Load() < int64(e.DownloadCount) && (!e.DownloadSkip || n.Info.SpeedDown.Average() == 0) {
 				speed := e.download(ctx, client.Client)
 				if speed > 0 {
-					n.Info.SpeedDown.Update(uint32(speed))
+					node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+						info.SpeedDown.Update(uint32(speed))
+					})
 					log.Debugf("node %s download speed: %d", raw["name"], speed)
 				}
 				if speed > e.DownloadSpeed {
-					downloadCount++
+					downloadCount.Add(1)
 				}
 			}
 			client.Timeout = time.Duration(e.Timeout) * time.Second
-			if e.Upload && uploadCount < e.UploadCount && (!e.UploadSkip || n.Info.SpeedUp.Average() == 0) {
+			if e.Upload && uploadCount.Load() < int64(e.UploadCount) && (!e.UploadSkip || n.Info.SpeedUp.Average() == 0) {
 				speed := e.upload(ctx, client.Client)
 				if speed > 0 {
-					n.Info.SpeedUp.Update(uint32(speed))
+					node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+						info.SpeedUp.Update(uint32(speed))
+					})
 					log.Debugf("node %s upload speed: %d", raw["name"], speed)
 				}
 				if speed > e.UploadSpeed {
-					uploadCount++
+					uploadCount.Add(1)
 				}
 			}
 		})
 	}
 	wg.Wait()
 	return checkModel.Result{
-		Msg:      fmt.Sprintf("success, download count: %d, upload count: %d", downloadCount, uploadCount),
+		Msg:      fmt.Sprintf("success, download count: %d, upload count: %d", downloadCount.Load(), uploadCount.Load()),
 		LastRun:  time.Now(),
 		Duration: time.Since(startTime).Milliseconds(),
 	}
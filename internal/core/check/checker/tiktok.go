@@ -75,15 +75,18 @@ func (e *TikTok) Run(ctx context.Context, log *log.Logger, subID []uint16) check
 				return
 			}
 
-			switch e.detectTikTok(ctx, raw) {
-			case 1:
-				n.Info.SetAliveStatus(nodeModel.TikTok, true)
-			case 2:
-				n.Info.SetAliveStatus(nodeModel.TikTokIDC, true)
-			default:
-				n.Info.SetAliveStatus(nodeModel.TikTok, false)
-				n.Info.SetAliveStatus(nodeModel.TikTokIDC, false)
-			}
+			result := e.detectTikTok(ctx, raw)
+			node.UpdateInfo(n.UniqueKey, func(info *nodeModel.Info) {
+				switch result {
+				case 1:
+					info.SetAliveStatus(nodeModel.TikTok, true)
+				case 2:
+					info.SetAliveStatus(nodeModel.TikTokIDC, true)
+				default:
+					info.SetAliveStatus(nodeModel.TikTok, false)
+					info.SetAliveStatus(nodeModel.TikTokIDC, false)
+				}
+			})
 		})
 	}
 	wg.Wait()
@@ -66,7 +66,12 @@ func CheckAdd(data *checkModel.Data) error {
 			}
 			log.Infof("%s task %d end", taskConfig.Type, data.ID)
 			op.UpdateCheckResult(data.ID, result)
+			node.Evict()
+			node.Rescore()
 			node.RefreshInfo()
+			if err := node.SyncInfo(); err != nil {
+				log.Warnf("failed to save node info: %v", err)
+			}
 		},
 		cronExpr: taskConfig.CronExpr,
 	})
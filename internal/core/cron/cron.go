@@ -14,10 +14,11 @@ type cronFunc struct {
 var scheduler = cron.New(cron.WithLocation(time.Local))
 
 const (
-	RunningStatus   = "running"
-	ScheduledStatus = "scheduled"
-	PendingStatus   = "pending"
-	DisabledStatus  = "disabled"
+	RunningStatus     = "running"
+	ScheduledStatus   = "scheduled"
+	PendingStatus     = "pending"
+	DisabledStatus    = "disabled"
+	CircuitOpenStatus = "circuit_open"
 )
 
 func Start() {
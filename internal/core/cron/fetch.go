@@ -32,7 +32,11 @@ func FetchLoad() {
 func FetchAdd(data *subModel.Data) error {
 	fetchFunc.Store(data.ID, cronFunc{
 		fn: func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if fetch.CircuitOpen(data.ID) {
+				log.Infof("fetch task %d skipped, circuit open", data.ID)
+				return
+			}
+			ctx, cancel := context.WithCancel(context.Background())
 			fetchRunning.Store(data.ID, cancel)
 			defer func() {
 				cancel()
@@ -40,11 +44,16 @@ func FetchAdd(data *subModel.Data) error {
 			}()
 			result := fetch.Do(ctx, data.ID, data.Config)
 			op.UpdateSubResult(ctx, data.ID, result)
+			if err := op.AddSubHistory(ctx, data.ID, result); err != nil {
+				log.Warnf("failed to add sub history: %v", err)
+			}
 			sub, err := op.GetSubByID(ctx, data.ID)
 			if err != nil {
 				log.Warnf("failed to get sub by id: %v", err)
 				return
 			}
+			checkQuota(sub)
+			checkScore(ctx, sub)
 			if !sub.Enable {
 				FetchDisable(data.ID)
 				log.Infof("fetch task %d auto disable", data.ID)
@@ -60,6 +69,7 @@ func FetchAdd(data *subModel.Data) error {
 
 func FetchRun(subID uint16) subModel.Result {
 	if ft, ok := fetchFunc.Load(subID); ok {
+		fetch.ResetBreaker(subID)
 		ft.fn()
 	} else {
 		log.Warnf("fetch task %d not found", subID)
@@ -95,6 +105,10 @@ func FetchEnable(subID uint16) error {
 	if ft, ok := fetchFunc.Load(subID); ok {
 		entryID, err := scheduler.AddFunc(ft.cronExpr,
 			func() {
+				if skipSlow(subID) {
+					log.Debugf("fetch task %d skipped, low score", subID)
+					return
+				}
 				time.Sleep(time.Duration(rand.Intn(100)) * time.Second)
 				ft.fn()
 			})
@@ -138,6 +152,8 @@ func FetchStop(subID uint16) error {
 	return nil
 }
 func FetchUpdate(data *subModel.Data) error {
+	fetch.ResetBreaker(data.ID)
+	fetchSlow.Delete(data.ID)
 	FetchRemove(data.ID)
 	FetchAdd(data)
 	return nil
@@ -146,6 +162,9 @@ func FetchStatus(subID uint16) string {
 	if _, ok := fetchRunning.Load(subID); ok {
 		return RunningStatus
 	}
+	if fetch.CircuitOpen(subID) {
+		return CircuitOpenStatus
+	}
 	if _, ok := fetchScheduled.Load(subID); ok {
 		return ScheduledStatus
 	}
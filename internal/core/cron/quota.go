@@ -0,0 +1,80 @@
+package cron
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	notifyModel "github.com/bestruirui/bestsub/internal/models/notify"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/modules/notify"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// quotaWarned 记录已发送提醒的订阅, 状态恢复前不再重复提醒
+var quotaWarned = generic.MapOf[uint16, string]{}
+
+func checkQuota(sub *subModel.Data) {
+	var result subModel.Result
+	if err := json.Unmarshal([]byte(sub.Result), &result); err != nil || result.Userinfo == nil {
+		return
+	}
+	info := result.Userinfo
+
+	var msg, state string
+	warnPercent := op.GetSettingInt(setting.SUB_QUOTA_WARN_PERCENT)
+	warnDays := op.GetSettingInt(setting.SUB_EXPIRE_WARN_DAYS)
+	if expireIn, ok := info.ExpireIn(); ok && warnDays > 0 && expireIn < time.Duration(warnDays)*24*time.Hour {
+		if expireIn <= 0 {
+			msg, state = "subscription expired", "expired"
+		} else {
+			msg, state = fmt.Sprintf("subscription expires in %.1f days", expireIn.Hours()/24), "expire"
+		}
+	}
+	if percent := info.UsedPercent(); warnPercent > 0 && percent >= uint8(min(warnPercent, 100)) {
+		if msg != "" {
+			msg += ", "
+		}
+		msg += fmt.Sprintf("traffic used %d%%", percent)
+		state += "traffic"
+	}
+
+	if msg == "" {
+		quotaWarned.Delete(sub.ID)
+		return
+	}
+	if last, ok := quotaWarned.Load(sub.ID); ok && last == state {
+		return
+	}
+	quotaWarned.Store(sub.ID, state)
+
+	log.Warnf("fetch task %d (%s): %s", sub.ID, sub.Name, msg)
+	expire := "-"
+	if info.Expire != 0 {
+		expire = time.Unix(info.Expire, 0).Format(time.DateOnly)
+	}
+	go notify.SendSystemNotify(notifyModel.TypeSubQuota, "订阅流量/到期提醒", subModel.QuotaNotify{
+		ID:     sub.ID,
+		Name:   sub.Name,
+		Msg:    msg,
+		Used:   formatBytes(info.Upload + info.Download),
+		Total:  formatBytes(info.Total),
+		Expire: expire,
+	})
+}
+
+func formatBytes(b uint64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := uint64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.2f %ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
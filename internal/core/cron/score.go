@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/generic"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+const (
+	ScorePolicyNone    = "none"
+	ScorePolicySlow    = "slow"
+	ScorePolicyDisable = "disable"
+)
+
+// scoreMinRuns 成功拉取次数达到该值后才应用评分策略, 避免新订阅的节点尚未测试完成就被处理
+const scoreMinRuns = 3
+
+// fetchSlow 评分过低被降频的订阅, 值为已跳过的定时次数
+var fetchSlow = generic.MapOf[uint16, int]{}
+
+// checkScore 按评分策略处理低质量订阅, 禁用时直接修改 sub.Enable
+func checkScore(ctx context.Context, sub *subModel.Data) {
+	policy := op.GetSettingStr(setting.SUB_SCORE_POLICY)
+	if policy != ScorePolicySlow && policy != ScorePolicyDisable {
+		fetchSlow.Delete(sub.ID)
+		return
+	}
+	var result subModel.Result
+	if err := json.Unmarshal([]byte(sub.Result), &result); err != nil {
+		return
+	}
+	if result.Success < scoreMinRuns {
+		return
+	}
+	score := result.Score(node.GetSubInfo(sub.ID))
+	threshold := op.GetSettingInt(setting.SUB_SCORE_THRESHOLD)
+	if int(score) >= threshold {
+		if _, ok := fetchSlow.Load(sub.ID); ok {
+			log.Infof("fetch task %d score %d recovered, restore frequency", sub.ID, score)
+			fetchSlow.Delete(sub.ID)
+		}
+		return
+	}
+	switch policy {
+	case ScorePolicyDisable:
+		sub.Enable = false
+		if err := op.UpdateSub(ctx, sub); err != nil {
+			log.Warnf("failed to disable sub %d: %v", sub.ID, err)
+			return
+		}
+		log.Warnf("fetch task %d (%s) score %d below %d, disabled", sub.ID, sub.Name, score, threshold)
+	case ScorePolicySlow:
+		if _, ok := fetchSlow.LoadOrStore(sub.ID, 0); !ok {
+			log.Warnf("fetch task %d (%s) score %d below %d, lower frequency", sub.ID, sub.Name, score, threshold)
+		}
+	}
+}
+
+// skipSlow 降频的订阅每 SUB_SCORE_SLOW_FACTOR 次定时只执行一次
+func skipSlow(subID uint16) bool {
+	skipped, ok := fetchSlow.Load(subID)
+	if !ok {
+		return false
+	}
+	factor := op.GetSettingInt(setting.SUB_SCORE_SLOW_FACTOR)
+	if skipped+1 >= factor {
+		fetchSlow.Store(subID, 0)
+		return false
+	}
+	fetchSlow.Store(subID, skipped+1)
+	return true
+}
@@ -0,0 +1,59 @@
+package fetch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+)
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+}
+
+var (
+	breakerMutex sync.Mutex
+	breakers     = make(map[uint16]*breakerState)
+)
+
+// CircuitOpen 订阅是否处于熔断冷却期
+func CircuitOpen(subID uint16) bool {
+	breakerMutex.Lock()
+	defer breakerMutex.Unlock()
+	b, ok := breakers[subID]
+	return ok && time.Now().Before(b.openUntil)
+}
+
+// ResetBreaker 清除订阅的熔断状态, 手动刷新或更新配置时调用
+func ResetBreaker(subID uint16) {
+	breakerMutex.Lock()
+	defer breakerMutex.Unlock()
+	delete(breakers, subID)
+}
+
+func breakerSuccess(subID uint16) {
+	ResetBreaker(subID)
+}
+
+// breakerFailure 记录一次失败, 连续失败达到阈值后熔断, 冷却结束后的首次请求仍失败则立即再次熔断
+func breakerFailure(subID uint16) bool {
+	threshold := op.GetSettingInt(setting.SUB_BREAKER_THRESHOLD)
+	if threshold <= 0 {
+		return false
+	}
+	breakerMutex.Lock()
+	defer breakerMutex.Unlock()
+	b, ok := breakers[subID]
+	if !ok {
+		b = &breakerState{}
+		breakers[subID] = b
+	}
+	b.failures++
+	if b.failures < threshold {
+		return false
+	}
+	b.openUntil = time.Now().Add(time.Duration(op.GetSettingInt(setting.SUB_BREAKER_COOLDOWN)) * time.Second)
+	return true
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"slices"
@@ -18,12 +20,12 @@ import (
 	"github.com/bestruirui/bestsub/internal/models/setting"
 	subModel "github.com/bestruirui/bestsub/internal/models/sub"
 	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/cespare/xxhash/v2"
 	"gopkg.in/yaml.v3"
 )
 
 func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 	startTime := time.Now()
-	retry := 0
 
 	var subConfig subModel.Config
 	if err := json.Unmarshal([]byte(config), &subConfig); err != nil {
@@ -33,44 +35,194 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 
 	log.Debugf("fetch task %d started", subID)
 
-	client := mihomo.Default(subConfig.Proxy)
-	if client == nil {
-		log.Warnf("fetch task %d failed: proxy config error", subID)
-		return createFailureResult("proxy config error", startTime)
+	var prev subModel.Result
+	if sub, err := op.GetSubByID(ctx, subID); err == nil {
+		json.Unmarshal([]byte(sub.Result), &prev)
+	}
+	// 节点池中已没有该订阅的节点或重启后尚未解析时不发送条件请求, 避免 304 导致无法恢复
+	if node.GetSubInfo(subID).Count == 0 || !node.HasOffers(subID) {
+		prev.ETag, prev.LastModified = "", ""
 	}
-	defer client.Release()
-	for retry < 3 {
-		time.Sleep(time.Duration(retry) * time.Second)
-		retry++
-		client.Timeout = time.Duration(subConfig.Timeout) * time.Second
-
-		req, err := http.NewRequestWithContext(ctx, "GET", subConfig.Url, nil)
-		if err != nil {
-			log.Warnf("fetch task %d failed: %v", subID, err)
-			continue
-		}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Warnf("fetch task %d failed: %v", subID, err)
-			continue
+	var contents [][]byte
+	var resp response
+	var err error
+	switch subConfig.Source() {
+	case subModel.SourceContent:
+		contents = [][]byte{[]byte(subConfig.Content)}
+	case subModel.SourceFile:
+		contents, err = readFiles(subConfig.Url)
+	default:
+		resp, err = download(ctx, subID, &subConfig, &prev)
+		contents = [][]byte{resp.body}
+	}
+	if errors.Is(err, errNotModified) {
+		breakerSuccess(subID)
+		log.Infof("fetch task %d not modified, duration: %dms", subID, uint16(time.Since(startTime).Milliseconds()))
+		result := createUnchangedResult(startTime)
+		result.Userinfo = parseUserinfo(resp.header)
+		result.StatusCode = resp.status
+		return result
+	}
+	if err != nil {
+		log.Warnf("fetch task %d failed: %v", subID, err)
+		if breakerFailure(subID) {
+			log.Warnf("fetch task %d circuit open after repeated failures", subID)
 		}
-		defer resp.Body.Close()
+		result := createFailureResult(err.Error(), startTime)
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			result.StatusCode = statusErr.code
+		}
+		return result
+	}
+	breakerSuccess(subID)
 
-		content, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Warnf("fetch task %d failed: %v", subID, err)
-			continue
+	hash := hashContents(contents)
+	// 节点池中已没有该订阅的节点时仍需重新提交, 避免内容不变导致永远无法恢复
+	// 重启后也需重新解析一次以恢复订阅提供的节点集合
+	if hash == prev.Hash && node.GetSubInfo(subID).Count > 0 && node.HasOffers(subID) {
+		log.Infof("fetch task %d content unchanged, duration: %dms", subID, uint16(time.Since(startTime).Milliseconds()))
+		result := createUnchangedResult(startTime)
+		result.Userinfo = parseUserinfo(resp.header)
+		result.StatusCode = resp.status
+		return result
+	}
+
+	stats := process(subID, &subConfig, contents)
+
+	log.Infof("fetch task %d completed, raw node count: %d, new node count: %d, duration: %dms",
+		subID, stats.count, stats.added, uint16(time.Since(startTime).Milliseconds()))
+	if failed := stats.failures.total(); failed > 0 {
+		log.Warnf("fetch task %d: %d nodes failed to parse", subID, failed)
+	}
+
+	result := createSuccessResult(uint32(stats.count), startTime, stats.count == 0)
+	result.UniqueCount = uint32(stats.unique)
+	result.NewCount = uint32(stats.added)
+	result.ParseErrors = stats.failures.list()
+	result.StatusCode = resp.status
+	result.Userinfo = parseUserinfo(resp.header)
+	result.ETag = resp.header.Get("ETag")
+	result.LastModified = resp.header.Get("Last-Modified")
+	result.Hash = hash
+	return result
+}
+
+var errNotModified = errors.New("not modified")
+
+// response 下载结果, 非 HTTP 来源时为零值
+type response struct {
+	body   []byte
+	header http.Header
+	status int
+}
+
+// download 每次尝试依次经过各个出口, 出口失败时(包括不可重试的状态码)立即切换到下一个, 全部失败后按退避策略重试
+// 全部出口都返回不可重试的状态码时不再重试
+func download(ctx context.Context, subID uint16, subConfig *subModel.Config, prev *subModel.Result) (response, error) {
+	policy := loadRetryPolicy(subConfig)
+	dialers := loadDialers(subID, subConfig)
+	var lastErr error
+	for attempt := 0; attempt < policy.attempts; attempt++ {
+		if err := policy.wait(ctx, attempt); err != nil {
+			return response{}, err
+		}
+		retry := false
+		for i := range dialers {
+			resp, err := fetchVia(ctx, &dialers[i], subConfig, prev)
+			if err == nil || errors.Is(err, errNotModified) {
+				return resp, err
+			}
+			var statusErr *statusError
+			if !errors.As(err, &statusErr) || policy.retryable(statusErr.code) {
+				retry = true
+			}
+			lastErr = err
+			log.Warnf("fetch task %d attempt %d/%d via %s failed: %v", subID, attempt+1, policy.attempts, dialers[i].name, err)
+		}
+		if !retry {
+			return response{}, lastErr
 		}
-		contentStr := subconv.ConvertData(string(content), "mihomo")
-		content = []byte(contentStr)
+	}
+	return response{}, fmt.Errorf("fetch failed after %d attempts: %w", policy.attempts, lastErr)
+}
 
-		globalProtocolFilterEnable := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_ENABLE)
-		globalProtocolFilterMode := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_MODE)
-		globalProtocolFilter := strings.Split(op.GetSettingStr(setting.NODE_PROTOCOL_FILTER), ",")
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.code)
+}
+
+// fetchVia 经指定出口发起一次请求
+func fetchVia(ctx context.Context, d *dialer, subConfig *subModel.Config, prev *subModel.Result) (response, error) {
+	client := d.client(subConfig)
+	if client == nil {
+		return response{}, errors.New("proxy config error")
+	}
+	defer client.Release()
+	client.Timeout = time.Duration(subConfig.Timeout) * time.Second
+	if client.Timeout <= 0 {
+		client.Timeout = defaultTimeout
+	}
+
+	req, err := newRequest(ctx, subConfig)
+	if err != nil {
+		return response{}, err
+	}
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return response{header: resp.Header, status: resp.StatusCode}, errNotModified
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return response{}, &statusError{code: resp.StatusCode}
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{body: content, header: resp.Header, status: resp.StatusCode}, nil
+}
+
+type processStats struct {
+	count    int // 提交的节点数量
+	unique   int // 其中去重后的数量
+	added    int // 其中新增待测的数量
+	failures parseReport
+}
+
+// process 解析订阅内容并按协议与字段过滤, 校验通过后提交到节点池
+func process(subID uint16, subConfig *subModel.Config, contents [][]byte) processStats {
+	var stats processStats
+	globalProtocolFilterEnable := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_ENABLE)
+	globalProtocolFilterMode := op.GetSettingBool(setting.NODE_PROTOCOL_FILTER_MODE)
+	globalProtocolFilter := strings.Split(op.GetSettingStr(setting.NODE_PROTOCOL_FILTER), ",")
+
+	filters := loadFilters(subID, subConfig)
+
+	var nodes []nodeModel.Base
+	var unique nodeModel.UniqueKey
+	var fields nodeFields
+	for _, content := range contents {
+		content, parseErrs := subconv.ToMihomo(content)
+		for _, err := range parseErrs {
+			log.Debugf("fetch task %d: parse failed: %v", subID, err)
+			stats.failures.add(err)
+		}
 
-		var nodes []nodeModel.Base
-		var unique nodeModel.UniqueKey
 		lines := bytes.Split(content, []byte("\n"))
 		lines = lines[1:]
 		for _, line := range lines {
@@ -79,6 +231,7 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 			}
 			line = line[4:]
 			if err := yaml.Unmarshal(line, &unique); err != nil {
+				stats.failures.add(err)
 				continue
 			}
 			if subConfig.ProtocolFilterEnable {
@@ -106,24 +259,55 @@ func Do(ctx context.Context, subID uint16, config string) subModel.Result {
 					}
 				}
 			}
+			if len(filters) > 0 {
+				fields = nodeFields{}
+				if err := yaml.Unmarshal(line, &fields); err != nil {
+					continue
+				}
+				if f := drop(filters, &fields); f != nil {
+					f.dropped++
+					continue
+				}
+			}
+			var raw map[string]any
+			if err := json.Unmarshal(line, &raw); err != nil {
+				stats.failures.add(err)
+				continue
+			}
+			if err := mihomo.Validate(raw); err != nil {
+				log.Debugf("fetch task %d: validate %v failed: %v", subID, raw["name"], err)
+				stats.failures.add(err)
+				continue
+			}
 			nodes = append(nodes, nodeModel.Base{
-				Raw:       line,
-				SubId:     subID,
-				UniqueKey: unique.Gen(),
+				Raw:   line,
+				SubId: subID,
 			})
 		}
+	}
+	raws := make([][]byte, len(nodes))
+	for i := range nodes {
+		raws[i] = nodes[i].Raw
+	}
+	for i, key := range node.GenKeys(raws) {
+		nodes[i].UniqueKey = key
+	}
 
-		count := len(nodes)
+	logFilters(subID, filters)
 
-		node.Add(&nodes)
+	keys := make(map[uint64]struct{}, len(nodes))
+	for _, n := range nodes {
+		keys[n.UniqueKey] = struct{}{}
+	}
+	stats.count = len(nodes)
+	stats.unique = len(keys)
 
-		log.Infof("fetch task %d completed, raw node count: %d,  duration: %dms",
-			subID, count, uint16(time.Since(startTime).Milliseconds()))
+	node.SetOffers(subID, keys)
+	stats.added = node.Add(&nodes)
 
-		return createSuccessResult(uint32(count), startTime, count == 0)
-	}
-	return createFailureResult("fetch task failed", startTime)
+	return stats
 }
+
 func createFailureResult(msg string, startTime time.Time) subModel.Result {
 	return subModel.Result{
 		Success:  0,
@@ -134,6 +318,23 @@ func createFailureResult(msg string, startTime time.Time) subModel.Result {
 	}
 }
 
+func createUnchangedResult(startTime time.Time) subModel.Result {
+	return subModel.Result{
+		Unchanged: 1,
+		Msg:       "sub content unchanged",
+		LastRun:   time.Now(),
+		Duration:  uint16(time.Since(startTime).Milliseconds()),
+	}
+}
+
+func hashContents(contents [][]byte) uint64 {
+	h := xxhash.New()
+	for _, content := range contents {
+		h.Write(content)
+	}
+	return h.Sum64()
+}
+
 func createSuccessResult(count uint32, startTime time.Time, nodeNull bool) subModel.Result {
 	nodeNullCount := uint16(0)
 	if nodeNull {
@@ -0,0 +1,89 @@
+package fetch
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bestruirui/bestsub/internal/config"
+)
+
+// readFiles 读取本地订阅文件, 支持 file:// 前缀、目录以及通配符
+// 每个文件单独返回, 以便不同格式的文件分别解析
+// 只能读取配置的订阅文件目录下的文件, 相对路径相对于该目录
+func readFiles(path string) ([][]byte, error) {
+	return readFilesIn(config.Base().Sub.FileDir, path)
+}
+
+func readFilesIn(baseDir, path string) ([][]byte, error) {
+	path = strings.TrimPrefix(path, "file://")
+	if path == "" {
+		return nil, fmt.Errorf("file path is empty")
+	}
+	base, err := filepath.Abs(baseDir)
+	if err != nil {
+		return nil, err
+	}
+	realBase, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return nil, fmt.Errorf("subscription file directory unavailable: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	path = filepath.Clean(path)
+	if !within(base, path) && !within(realBase, path) {
+		return nil, fmt.Errorf("file path outside subscription file directory")
+	}
+
+	var files []string
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	} else {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, err
+		}
+		files = matches
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file matched: %s", path)
+	}
+
+	contents := make([][]byte, 0, len(files))
+	for _, file := range files {
+		// 符号链接解析后仍需位于目录内
+		real, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			return nil, err
+		}
+		if !within(realBase, real) {
+			return nil, fmt.Errorf("file %s links outside subscription file directory", filepath.Base(file))
+		}
+		content, err := os.ReadFile(real)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, content)
+	}
+	return contents, nil
+}
+
+// within path 是否为 base 或其下的路径, 两者均需为清理后的绝对路径
+func within(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,59 @@
+package fetch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadFilesConfined(t *testing.T) {
+	root := t.TempDir()
+	base := filepath.Join(root, "subs")
+	outside := filepath.Join(root, "secret.txt")
+	for path, content := range map[string]string{
+		filepath.Join(base, "a.txt"):        "a",
+		filepath.Join(base, "dir", "b.txt"): "b",
+		outside:                             "secret",
+	} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(base, "link.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(root, filepath.Join(base, "up")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		files int
+	}{
+		{"a.txt", 1},
+		{"file://dir/b.txt", 1},
+		{"dir", 1},
+		{"*.txt", -1}, // 匹配到指向目录外的链接
+		{"a*", 1},
+		{filepath.Join(base, "a.txt"), 1},
+		{"../secret.txt", -1},
+		{"dir/../../secret.txt", -1},
+		{outside, -1},
+		{"link.txt", -1},
+		{"up/secret.txt", -1},
+		{"/etc/passwd", -1},
+	}
+	for _, tt := range tests {
+		contents, err := readFilesIn(base, tt.path)
+		if tt.files < 0 {
+			if err == nil {
+				t.Errorf("readFiles(%q) read %d files outside the directory", tt.path, len(contents))
+			}
+			continue
+		}
+		if err != nil || len(contents) != tt.files {
+			t.Errorf("readFiles(%q) = %d files, %v, want %d", tt.path, len(contents), err, tt.files)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package fetch
+
+import (
+	"encoding/json"
+	"regexp"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type nodeFields struct {
+	Name    string `yaml:"name"`
+	Server  string `yaml:"server"`
+	Port    string `yaml:"port"`
+	Network string `yaml:"network"`
+	Cipher  string `yaml:"cipher"`
+}
+
+type fieldFilter struct {
+	subModel.NodeFilter
+	scope   string
+	re      *regexp.Regexp
+	dropped int
+}
+
+// loadFilters 编译订阅与全局的字段过滤规则, 无效的规则会被忽略
+func loadFilters(subID uint16, subConfig *subModel.Config) []*fieldFilter {
+	var global []subModel.NodeFilter
+	if raw := op.GetSettingStr(setting.NODE_FIELD_FILTER); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &global); err != nil {
+			log.Warnf("invalid global node field filter: %v", err)
+		}
+	}
+	var filters []*fieldFilter
+	scopes := []string{"sub", "global"}
+	for i, rules := range [][]subModel.NodeFilter{subConfig.NodeFilter, global} {
+		scope := scopes[i]
+		for _, rule := range rules {
+			re, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				log.Warnf("fetch task %d: invalid %s filter %s /%s/: %v", subID, scope, rule.Field, rule.Pattern, err)
+				continue
+			}
+			filters = append(filters, &fieldFilter{NodeFilter: rule, scope: scope, re: re})
+		}
+	}
+	return filters
+}
+
+// drop 返回第一条拒绝该节点的规则, 节点保留时返回 nil
+func drop(filters []*fieldFilter, fields *nodeFields) *fieldFilter {
+	for _, f := range filters {
+		var value string
+		switch f.Field {
+		case "name":
+			value = fields.Name
+		case "server":
+			value = fields.Server
+		case "port":
+			value = fields.Port
+		case "network":
+			value = fields.Network
+			if value == "" {
+				value = "tcp"
+			}
+		case "cipher":
+			value = fields.Cipher
+		default:
+			continue
+		}
+		if f.re.MatchString(value) == f.Exclude {
+			return f
+		}
+	}
+	return nil
+}
+
+func logFilters(subID uint16, filters []*fieldFilter) {
+	for _, f := range filters {
+		mode := "include"
+		if f.Exclude {
+			mode = "exclude"
+		}
+		log.Infof("fetch task %d: %s filter %s %s /%s/ dropped %d nodes", subID, f.scope, mode, f.Field, f.Pattern, f.dropped)
+	}
+}
@@ -0,0 +1,86 @@
+package fetch
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/bestruirui/bestsub/internal/core/mihomo"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/core/subconv"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+	"gopkg.in/yaml.v3"
+)
+
+// AddManual 解析手动提交的节点链接或 yaml 片段并加入待测试队列, 不经过订阅过滤
+func AddManual(content []byte) nodeModel.AddResponse {
+	var result nodeModel.AddResponse
+	if proxies, ok := bareProxies(content); ok {
+		content = subconv.Marshal(proxies)
+	} else {
+		var parseErrs []error
+		content, parseErrs = subconv.ToMihomo(content)
+		for _, err := range parseErrs {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	var nodes []nodeModel.Base
+	lines := bytes.Split(content, []byte("\n"))
+	if len(lines) > 0 {
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		if len(line) <= 4 {
+			continue
+		}
+		line = line[4:]
+		var raw map[string]any
+		if err := json.Unmarshal(line, &raw); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if err := mihomo.Validate(raw); err != nil {
+			log.Debugf("manual node %v validate failed: %v", raw["name"], err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		nodes = append(nodes, nodeModel.Base{Raw: line})
+	}
+	raws := make([][]byte, len(nodes))
+	for i := range nodes {
+		raws[i] = nodes[i].Raw
+	}
+	for i, key := range node.GenKeys(raws) {
+		nodes[i].UniqueKey = key
+	}
+	result.Parsed = uint32(len(nodes))
+	result.Queued = uint32(node.Add(&nodes))
+	return result
+}
+
+// bareProxies 解析不带 proxies 键的 yaml 节点, 内容为单个节点或节点列表时返回 true
+func bareProxies(content []byte) ([]map[string]any, bool) {
+	var doc any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, false
+	}
+	var items []any
+	switch v := doc.(type) {
+	case map[string]any:
+		items = []any{v}
+	case []any:
+		items = v
+	default:
+		return nil, false
+	}
+	proxies := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		proxy, ok := item.(map[string]any)
+		if !ok || proxy["type"] == nil {
+			return nil, false
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, len(proxies) > 0
+}
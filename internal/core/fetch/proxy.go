@@ -0,0 +1,60 @@
+package fetch
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/bestruirui/bestsub/internal/core/mihomo"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// defaultProxyNodeLimit 经节点池代理下载时默认最多尝试的候选节点数
+const defaultProxyNodeLimit = 3
+
+// dialer 下载使用的出口, raw 为空时使用 mihomo.Default
+type dialer struct {
+	name string
+	raw  map[string]any
+}
+
+// loadDialers 返回本次下载依次尝试的出口
+// 配置了 proxy_node 时从节点池中按延迟升序挑选候选节点, 没有可用节点时回退到默认出口
+func loadDialers(subID uint16, subConfig *subModel.Config) []dialer {
+	if subConfig.ProxyNode == nil {
+		return []dialer{{name: "default"}}
+	}
+	nodes := *node.GetByFilter(*subConfig.ProxyNode)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Info.Delay.Average() < nodes[j].Info.Delay.Average()
+	})
+	limit := subConfig.ProxyNodeLimit
+	if limit <= 0 {
+		limit = defaultProxyNodeLimit
+	}
+	var dialers []dialer
+	for _, n := range nodes {
+		if len(dialers) >= limit {
+			break
+		}
+		var raw map[string]any
+		if err := json.Unmarshal(n.Raw, &raw); err != nil {
+			continue
+		}
+		name, _ := raw["name"].(string)
+		dialers = append(dialers, dialer{name: name, raw: raw})
+	}
+	if len(dialers) == 0 {
+		log.Warnf("fetch task %d: no pool node matched proxy filter, fallback to default", subID)
+		return []dialer{{name: "default"}}
+	}
+	return dialers
+}
+
+func (d *dialer) client(subConfig *subModel.Config) *mihomo.HC {
+	if d.raw == nil {
+		return mihomo.Default(subConfig.Proxy)
+	}
+	return mihomo.Proxy(d.raw)
+}
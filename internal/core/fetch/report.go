@@ -0,0 +1,73 @@
+package fetch
+
+import (
+	"slices"
+	"strings"
+
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+// maxErrorSamples 每种错误类型最多保留的不同错误信息数
+const maxErrorSamples = 3
+
+// parseReport 按错误类型汇总解析与校验失败的节点
+type parseReport struct {
+	classes map[string]*subModel.ParseError
+}
+
+func (r *parseReport) add(err error) {
+	if r.classes == nil {
+		r.classes = make(map[string]*subModel.ParseError)
+	}
+	class := classifyError(err)
+	e := r.classes[class]
+	if e == nil {
+		e = &subModel.ParseError{Class: class}
+		r.classes[class] = e
+	}
+	e.Count++
+	if msg := err.Error(); len(e.Samples) < maxErrorSamples && !slices.Contains(e.Samples, msg) {
+		e.Samples = append(e.Samples, msg)
+	}
+}
+
+func (r *parseReport) total() uint32 {
+	var total uint32
+	for _, e := range r.classes {
+		total += e.Count
+	}
+	return total
+}
+
+// list 按数量降序返回
+func (r *parseReport) list() []subModel.ParseError {
+	result := make([]subModel.ParseError, 0, len(r.classes))
+	for _, e := range r.classes {
+		result = append(result, *e)
+	}
+	slices.SortFunc(result, func(a, b subModel.ParseError) int {
+		if a.Count != b.Count {
+			return int(b.Count) - int(a.Count)
+		}
+		return strings.Compare(a.Class, b.Class)
+	})
+	return result
+}
+
+// classifyError 根据 subconv 与 mihomo 的错误信息归类
+func classifyError(err error) string {
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "unsupport"):
+		return subModel.ParseErrorUnsupported
+	case strings.Contains(msg, "cipher"), strings.Contains(msg, "method"), strings.Contains(msg, "encrypt"):
+		return subModel.ParseErrorCipher
+	case strings.Contains(msg, "missing"), strings.Contains(msg, "unset"), strings.Contains(msg, "required"), strings.Contains(msg, "empty"):
+		return subModel.ParseErrorMissingField
+	case strings.Contains(msg, "invalid"), strings.Contains(msg, "yaml"), strings.Contains(msg, "json"),
+		strings.Contains(msg, "base64"), strings.Contains(msg, "parse"), strings.Contains(msg, "decode"):
+		return subModel.ParseErrorFormat
+	default:
+		return subModel.ParseErrorOther
+	}
+}
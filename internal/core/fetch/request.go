@@ -0,0 +1,39 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/ua"
+)
+
+// newRequest 按订阅配置构造请求, 应用请求方法、User-Agent、额外请求头与认证
+func newRequest(ctx context.Context, subConfig *subModel.Config) (*http.Request, error) {
+	method := strings.ToUpper(strings.TrimSpace(subConfig.Method))
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, subConfig.Url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if subConfig.UserAgent != "" {
+		req.Header.Set("User-Agent", ua.Preset(subConfig.UserAgent))
+	}
+	for k, v := range subConfig.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	switch strings.ToLower(subConfig.AuthType) {
+	case "basic":
+		req.SetBasicAuth(subConfig.Username, subConfig.Password)
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+subConfig.Token)
+	}
+	return req, nil
+}
@@ -0,0 +1,83 @@
+package fetch
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type retryPolicy struct {
+	attempts   int
+	backoff    time.Duration
+	backoffMax time.Duration
+	status     []int
+}
+
+// loadRetryPolicy 订阅配置优先, 未设置的项使用全局设置
+func loadRetryPolicy(subConfig *subModel.Config) retryPolicy {
+	p := retryPolicy{
+		attempts:   op.GetSettingInt(setting.TASK_MAX_RETRY),
+		backoff:    time.Duration(op.GetSettingInt(setting.SUB_RETRY_BACKOFF)) * time.Millisecond,
+		backoffMax: time.Duration(op.GetSettingInt(setting.SUB_RETRY_BACKOFF_MAX)) * time.Millisecond,
+		status:     parseStatus(op.GetSettingStr(setting.SUB_RETRY_STATUS)),
+	}
+	if subConfig.Retry > 0 {
+		p.attempts = subConfig.Retry
+	}
+	if subConfig.RetryBackoff > 0 {
+		p.backoff = time.Duration(subConfig.RetryBackoff) * time.Millisecond
+	}
+	if len(subConfig.RetryStatus) > 0 {
+		p.status = subConfig.RetryStatus
+	}
+	if p.attempts <= 0 {
+		p.attempts = 1
+	}
+	if p.backoffMax < p.backoff {
+		p.backoffMax = p.backoff
+	}
+	return p
+}
+
+func (p *retryPolicy) retryable(status int) bool {
+	return slices.Contains(p.status, status)
+}
+
+// wait 指数退避并附带抖动, 第 n 次重试等待 [d/2, d), d = backoff * 2^(n-1)
+func (p *retryPolicy) wait(ctx context.Context, retry int) error {
+	if retry <= 0 || p.backoff <= 0 {
+		return ctx.Err()
+	}
+	d := p.backoff << (retry - 1)
+	if d > p.backoffMax || d <= 0 {
+		d = p.backoffMax
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func parseStatus(s string) []int {
+	var status []int
+	for _, v := range strings.Split(s, ",") {
+		if code, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			status = append(status, code)
+		}
+	}
+	return status
+}
@@ -0,0 +1,56 @@
+package fetch
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+// parseUserinfo 解析 Subscription-Userinfo、profile-title 与 profile-update-interval 响应头
+// 三者均不存在时返回 nil
+func parseUserinfo(header http.Header) *subModel.Userinfo {
+	raw := header.Get("Subscription-Userinfo")
+	title := header.Get("Profile-Title")
+	interval := header.Get("Profile-Update-Interval")
+	if raw == "" && title == "" && interval == "" {
+		return nil
+	}
+
+	var info subModel.Userinfo
+	for _, field := range strings.Split(raw, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			continue
+		}
+		// 部分机场会返回科学计数法或小数
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || f < 0 {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "upload":
+			info.Upload = uint64(f)
+		case "download":
+			info.Download = uint64(f)
+		case "total":
+			info.Total = uint64(f)
+		case "expire":
+			info.Expire = int64(f)
+		}
+	}
+
+	if strings.HasPrefix(title, "base64:") {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(title, "base64:")); err == nil {
+			title = string(decoded)
+		}
+	}
+	info.Title = title
+
+	if hours, err := strconv.ParseUint(strings.TrimSpace(interval), 10, 16); err == nil {
+		info.UpdateInterval = uint16(hours)
+	}
+	return &info
+}
@@ -147,6 +147,15 @@ func Proxy(raw map[string]any) *HC {
 	return &HC{Client: client, proxy: proxy}
 }
 
+// Validate 检查节点配置能否被 mihomo 解析
+func Validate(raw map[string]any) error {
+	proxy, err := adapter.ParseProxy(raw)
+	if proxy != nil {
+		proxy.Close()
+	}
+	return err
+}
+
 func (h *HC) Release() {
 	if h.Client == nil {
 		return
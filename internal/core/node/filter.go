@@ -0,0 +1,101 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// matcher 解析后的过滤条件, 订阅标签在每次过滤时解析为订阅 ID, 新增的订阅因此自动生效
+type matcher struct {
+	filter      *nodeModel.Filter
+	tagSubs     []uint16 // 含任一 SubTag 的订阅, nil 表示未按标签筛选
+	tagExcluded []uint16 // 含任一 SubTagExclude 的订阅
+}
+
+func newMatcher(filter *nodeModel.Filter) *matcher {
+	m := &matcher{filter: filter}
+	if len(filter.SubTag) == 0 && len(filter.SubTagExclude) == 0 {
+		return m
+	}
+	if len(filter.SubTag) > 0 {
+		m.tagSubs = []uint16{}
+	}
+	subs, _ := op.GetSubList(context.Background())
+	for _, sub := range subs {
+		var tags []string
+		json.Unmarshal([]byte(sub.Tags), &tags)
+		hasAny := func(want []string) bool {
+			return slices.ContainsFunc(want, func(t string) bool { return slices.Contains(tags, t) })
+		}
+		if len(filter.SubTag) > 0 && hasAny(filter.SubTag) {
+			m.tagSubs = append(m.tagSubs, sub.ID)
+		}
+		if hasAny(filter.SubTagExclude) {
+			m.tagExcluded = append(m.tagExcluded, sub.ID)
+		}
+	}
+	return m
+}
+
+func (m *matcher) match(node *nodeModel.Data) bool {
+	filter := m.filter
+	if !filter.IncludeQuarantined && node.Info.Quarantined() {
+		return false
+	}
+	if m.tagSubs != nil && !slices.Contains(m.tagSubs, node.Base.SubId) {
+		return false
+	}
+	if slices.Contains(m.tagExcluded, node.Base.SubId) {
+		return false
+	}
+	if len(filter.SubId) > 0 {
+		if filter.SubIdExclude && slices.Contains(filter.SubId, node.Base.SubId) {
+			return false
+		}
+		if !filter.SubIdExclude && !slices.Contains(filter.SubId, node.Base.SubId) {
+			return false
+		}
+	}
+	if filter.AliveStatus != 0 && node.Info.AliveStatus&filter.AliveStatus != filter.AliveStatus {
+		return false
+	}
+	if len(filter.Country) > 0 {
+		if filter.CountryExclude && slices.Contains(filter.Country, node.Info.Country) {
+			return false
+		}
+		if !filter.CountryExclude && !slices.Contains(filter.Country, node.Info.Country) {
+			return false
+		}
+	}
+	if filter.SpeedUpMore != 0 && node.Info.SpeedUp.Average() < filter.SpeedUpMore {
+		return false
+	}
+	if filter.SpeedDownMore != 0 && node.Info.SpeedDown.Average() < filter.SpeedDownMore {
+		return false
+	}
+	if filter.DelayLessThan != 0 && node.Info.Delay.Average() > filter.DelayLessThan {
+		return false
+	}
+	if filter.RiskLessThan != 0 && node.Info.Risk > filter.RiskLessThan {
+		return false
+	}
+	if len(filter.Label) > 0 && !slices.ContainsFunc(filter.Label, func(l string) bool { return slices.Contains(node.Labels, l) }) {
+		return false
+	}
+	for _, c := range filter.Capability {
+		if !node.Info.HasCapability(c) {
+			return false
+		}
+	}
+	if slices.ContainsFunc(filter.CapabilityExclude, node.Info.HasCapability) {
+		return false
+	}
+	if len(filter.LabelExclude) > 0 && slices.ContainsFunc(filter.LabelExclude, func(l string) bool { return slices.Contains(node.Labels, l) }) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package node
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// RecordAlive 记录一次存活检测结果, 更新连续成功/失败次数与抖动计数
+// 抖动窗口内状态切换次数达到阈值时隔离节点, 隔离期间不出现在分享中
+func RecordAlive(info *nodeModel.Info, alive bool) {
+	now := time.Now()
+	info.RecordAlive(alive, now)
+
+	window := time.Duration(op.GetSettingInt(setting.NODE_FLAP_WINDOW)) * time.Hour
+	if window > 0 && now.Sub(time.Unix(info.FlapSince, 0)) > window {
+		info.Flaps = 0
+		info.FlapSince = now.Unix()
+	}
+	threshold := op.GetSettingInt(setting.NODE_FLAP_THRESHOLD)
+	if threshold > 0 && int(info.Flaps) >= threshold {
+		hours := op.GetSettingInt(setting.NODE_QUARANTINE_HOURS)
+		info.QuarantineUntil = now.Add(time.Duration(hours) * time.Hour).Unix()
+		info.Flaps = 0
+		info.FlapSince = now.Unix()
+	}
+}
+
+// aliveRunFailed 最近一次存活检测没有任何节点存活, 通常是本机网络故障而不是节点失效
+var aliveRunFailed atomic.Bool
+
+// AliveRunDone 记录一次存活检测的结果
+func AliveRunDone(alive, dead int) {
+	aliveRunFailed.Store(alive == 0 && dead > 0)
+}
+
+// Evict 移出连续失败次数或死亡时长超过设置的节点, 返回移出的数量
+// 最近一次存活检测全部失败时不淘汰
+func Evict() int {
+	failCount := op.GetSettingInt(setting.NODE_EVICT_FAIL_COUNT)
+	deadHours := op.GetSettingInt(setting.NODE_EVICT_DEAD_HOURS)
+	if failCount <= 0 && deadHours <= 0 {
+		return 0
+	}
+	if aliveRunFailed.Load() {
+		log.Warnf("last alive check found no alive node, skip eviction")
+		return 0
+	}
+	now := time.Now()
+
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	poolMutex.Lock()
+	var removed []uint64
+	kept := pool[:0]
+	for _, n := range pool {
+		if IsPinned(n.UniqueKey) {
+			kept = append(kept, n)
+			continue
+		}
+		dead := n.Info.ConsecutiveFail > 0 && ((failCount > 0 && int(n.Info.ConsecutiveFail) >= failCount) ||
+			(deadHours > 0 && n.Info.LastAlive != 0 && now.Sub(time.Unix(n.Info.LastAlive, 0)) >= time.Duration(deadHours)*time.Hour))
+		if dead {
+			log.Debugf("node %d evicted, %d consecutive failures", n.UniqueKey, n.Info.ConsecutiveFail)
+			nodeExist.Remove(n.UniqueKey)
+			removed = append(removed, n.UniqueKey)
+			continue
+		}
+		kept = append(kept, n)
+	}
+	pool = kept
+	reindexLocked()
+	poolMutex.Unlock()
+
+	persist(nil, removed)
+	if len(removed) > 0 {
+		log.Infof("%d dead nodes evicted from pool", len(removed))
+	}
+	return len(removed)
+}
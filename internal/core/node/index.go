@@ -0,0 +1,171 @@
+package node
+
+import (
+	"math/bits"
+	"slices"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// poolIndex 节点池的二级索引, 保存节点在 pool 中的下标(升序), 由 poolMutex 保护
+// 节点增删或重新排序后整体重建, 检测结果只增量调整国家、检测位与能力
+type poolIndex struct {
+	key        map[uint64]int
+	sub        map[uint16][]int
+	country    map[string][]int
+	label      map[string][]int
+	capability map[string][]int
+	status     [64][]int // 每个检测位已置位的节点
+}
+
+var index = newPoolIndex()
+
+func newPoolIndex() poolIndex {
+	return poolIndex{
+		key:        make(map[uint64]int),
+		sub:        make(map[uint16][]int),
+		country:    make(map[string][]int),
+		label:      make(map[string][]int),
+		capability: make(map[string][]int),
+	}
+}
+
+// reindexLocked 根据 pool 重建索引, 调用方需持有 poolMutex 写锁
+func reindexLocked() {
+	idx := newPoolIndex()
+	for i := range pool {
+		n := &pool[i]
+		idx.key[n.UniqueKey] = i
+		idx.sub[n.SubId] = append(idx.sub[n.SubId], i)
+		idx.country[n.Info.Country] = append(idx.country[n.Info.Country], i)
+		for _, label := range n.Labels {
+			idx.label[label] = append(idx.label[label], i)
+		}
+		for _, c := range n.Info.Capabilities {
+			idx.capability[c] = append(idx.capability[c], i)
+		}
+		for status := n.Info.AliveStatus; status != 0; status &= status - 1 {
+			bit := bits.TrailingZeros64(status)
+			idx.status[bit] = append(idx.status[bit], i)
+		}
+	}
+	index = idx
+}
+
+// indexLocked 返回节点在池中的下标, 调用方需持有 poolMutex
+func indexLocked(key uint64) int {
+	if i, ok := index.key[key]; ok {
+		return i
+	}
+	return -1
+}
+
+// moveLocked 节点的国家、检测位或能力变化后调整索引
+func (idx *poolIndex) moveLocked(i int, oldCountry string, oldStatus uint64, oldCapabilities []string, info *nodeModel.Info) {
+	if oldCountry != info.Country {
+		idx.country[oldCountry] = remove(idx.country[oldCountry], i)
+		if len(idx.country[oldCountry]) == 0 {
+			delete(idx.country, oldCountry)
+		}
+		idx.country[info.Country] = insert(idx.country[info.Country], i)
+	}
+	for changed := oldStatus ^ info.AliveStatus; changed != 0; changed &= changed - 1 {
+		bit := bits.TrailingZeros64(changed)
+		if info.AliveStatus&(1<<bit) != 0 {
+			idx.status[bit] = insert(idx.status[bit], i)
+		} else {
+			idx.status[bit] = remove(idx.status[bit], i)
+		}
+	}
+	for _, c := range oldCapabilities {
+		if !info.HasCapability(c) {
+			idx.capability[c] = remove(idx.capability[c], i)
+			if len(idx.capability[c]) == 0 {
+				delete(idx.capability, c)
+			}
+		}
+	}
+	for _, c := range info.Capabilities {
+		if _, ok := slices.BinarySearch(oldCapabilities, c); !ok {
+			idx.capability[c] = insert(idx.capability[c], i)
+		}
+	}
+}
+
+// candidates 根据过滤条件从索引中取出最小的候选集合, 返回 false 表示需要扫描整个节点池
+// 候选节点仍需经过 matcher 完整校验
+func (idx *poolIndex) candidates(m *matcher) ([]int, bool) {
+	filter := m.filter
+	var best []int
+	found := false
+	pick := func(list []int) {
+		if !found || len(list) < len(best) {
+			best = list
+			found = true
+		}
+	}
+	if len(filter.SubId) > 0 && !filter.SubIdExclude {
+		lists := make([][]int, 0, len(filter.SubId))
+		for _, id := range filter.SubId {
+			lists = append(lists, idx.sub[id])
+		}
+		pick(union(lists))
+	}
+	if m.tagSubs != nil {
+		lists := make([][]int, 0, len(m.tagSubs))
+		for _, id := range m.tagSubs {
+			lists = append(lists, idx.sub[id])
+		}
+		pick(union(lists))
+	}
+	if len(filter.Country) > 0 && !filter.CountryExclude {
+		lists := make([][]int, 0, len(filter.Country))
+		for _, c := range filter.Country {
+			lists = append(lists, idx.country[c])
+		}
+		pick(union(lists))
+	}
+	if len(filter.Label) > 0 {
+		lists := make([][]int, 0, len(filter.Label))
+		for _, label := range filter.Label {
+			lists = append(lists, idx.label[label])
+		}
+		pick(union(lists))
+	}
+	for status := filter.AliveStatus; status != 0; status &= status - 1 {
+		pick(idx.status[bits.TrailingZeros64(status)])
+	}
+	for _, c := range filter.Capability {
+		pick(idx.capability[c])
+	}
+	return best, found
+}
+
+// union 合并多个有序下标列表
+func union(lists [][]int) []int {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	var result []int
+	for _, list := range lists {
+		result = append(result, list...)
+	}
+	slices.Sort(result)
+	return slices.Compact(result)
+}
+
+func insert(list []int, i int) []int {
+	pos, ok := slices.BinarySearch(list, i)
+	if ok {
+		return list
+	}
+	return slices.Insert(list, pos, i)
+}
+
+func remove(list []int, i int) []int {
+	pos, ok := slices.BinarySearch(list, i)
+	if !ok {
+		return list
+	}
+	return slices.Delete(list, pos, pos+1)
+}
@@ -0,0 +1,252 @@
+package node
+
+import (
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// checkIndex 逐个节点校验索引与节点池一致
+func checkIndex(t *testing.T) {
+	t.Helper()
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+	if len(index.key) != len(pool) {
+		t.Fatalf("key index has %d entries, pool has %d nodes", len(index.key), len(pool))
+	}
+	checkList := func(name string, list []int, want func(n *nodeModel.Data) bool) int {
+		if !slices.IsSorted(list) {
+			t.Fatalf("%s index not sorted: %v", name, list)
+		}
+		for _, i := range list {
+			if i >= len(pool) || !want(&pool[i]) {
+				t.Fatalf("%s index contains wrong position %d", name, i)
+			}
+		}
+		return len(list)
+	}
+	var subs, countries, labels, capabilities, status int
+	for i := range pool {
+		n := &pool[i]
+		if j, ok := index.key[n.UniqueKey]; !ok || j != i {
+			t.Fatalf("key %d indexed at %d, want %d", n.UniqueKey, j, i)
+		}
+		subs++
+		countries++
+		labels += len(n.Labels)
+		capabilities += len(n.Info.Capabilities)
+		status += bits.OnesCount64(n.Info.AliveStatus)
+	}
+	for id, list := range index.sub {
+		subs -= checkList("sub", list, func(n *nodeModel.Data) bool { return n.SubId == id })
+	}
+	for c, list := range index.country {
+		countries -= checkList("country", list, func(n *nodeModel.Data) bool { return n.Info.Country == c })
+	}
+	for label, list := range index.label {
+		labels -= checkList("label", list, func(n *nodeModel.Data) bool { return slices.Contains(n.Labels, label) })
+	}
+	for c, list := range index.capability {
+		capabilities -= checkList("capability", list, func(n *nodeModel.Data) bool { return n.Info.HasCapability(c) })
+	}
+	for bit, list := range index.status {
+		status -= checkList("status", list, func(n *nodeModel.Data) bool { return n.Info.AliveStatus&(1<<bit) != 0 })
+	}
+	if subs != 0 || countries != 0 || labels != 0 || capabilities != 0 || status != 0 {
+		t.Fatalf("index misses nodes: sub %d, country %d, label %d, capability %d, status %d",
+			subs, countries, labels, capabilities, status)
+	}
+}
+
+// scanByFilter 不使用索引的线性扫描, 作为 GetByFilter 的对照
+func scanByFilter(filter nodeModel.Filter) []nodeModel.Data {
+	m := newMatcher(&filter)
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+	var result []nodeModel.Data
+	for i := range pool {
+		if m.match(&pool[i]) {
+			result = append(result, snapshot(pool[i]))
+		}
+	}
+	return result
+}
+
+// scanBySubId 不使用索引的线性扫描, 作为 GetBySubId 的对照
+func scanBySubId(subId []uint16) []nodeModel.Data {
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+	var result []nodeModel.Data
+	for i := range pool {
+		if slices.Contains(subId, pool[i].SubId) {
+			result = append(result, snapshot(pool[i]))
+		}
+	}
+	return result
+}
+
+func keysOf(nodes []nodeModel.Data) []uint64 {
+	keys := make([]uint64, len(nodes))
+	for i := range nodes {
+		keys[i] = nodes[i].UniqueKey
+	}
+	return keys
+}
+
+var testFilters = []nodeModel.Filter{
+	{},
+	{SubId: []uint16{3}},
+	{SubId: []uint16{1, 2}, AliveStatus: nodeModel.Alive},
+	{SubId: []uint16{4}, SubIdExclude: true, Country: []string{"JP"}},
+	{Country: []string{"US", "HK"}, DelayLessThan: 800},
+	{Country: []string{"US"}, CountryExclude: true, AliveStatus: nodeModel.Alive | nodeModel.TikTok},
+	{Capability: []string{"netflix"}, CapabilityExclude: []string{"chatgpt"}},
+	{Label: []string{"fast"}, LabelExclude: []string{"stable"}},
+	{AliveStatus: nodeModel.Country, RiskLessThan: 50},
+}
+
+// checkFilters 索引查询与线性扫描结果一致
+func checkFilters(t *testing.T) {
+	t.Helper()
+	for _, filter := range testFilters {
+		got := keysOf(*GetByFilter(filter))
+		want := keysOf(scanByFilter(filter))
+		if !slices.Equal(got, want) {
+			t.Fatalf("GetByFilter(%+v) returned %d nodes, linear scan %d", filter, len(got), len(want))
+		}
+	}
+	for _, subs := range [][]uint16{{1}, {2, 5}, {99}} {
+		got := keysOf(*GetBySubId(subs))
+		want := keysOf(scanBySubId(subs))
+		if !slices.Equal(got, want) {
+			t.Fatalf("GetBySubId(%v) returned %d nodes, linear scan %d", subs, len(got), len(want))
+		}
+	}
+}
+
+func TestIndexAfterMerge(t *testing.T) {
+	seedPool(300, 200, 5)
+	r := rand.New(rand.NewSource(2))
+	var batch []nodeModel.Data
+	for i := range 200 {
+		batch = append(batch, testNode(r, uint64(10000+i), 5))
+	}
+	mergeNodesToPool(batch)
+	if len(pool) != 300 {
+		t.Fatalf("pool has %d nodes after merge, want 300", len(pool))
+	}
+	checkIndex(t)
+	checkFilters(t)
+}
+
+func TestIndexAfterDelete(t *testing.T) {
+	seedPool(300, 200, 5)
+	for _, key := range []uint64{1, 50, 200} {
+		if !Delete(key) {
+			t.Fatalf("node %d not deleted", key)
+		}
+	}
+	if Delete(1) {
+		t.Fatal("deleted node deleted again")
+	}
+	checkIndex(t)
+	checkFilters(t)
+
+	DeleteBySubId(2)
+	if len(*GetBySubId([]uint16{2})) != 0 {
+		t.Fatal("nodes of deleted subscription still in pool")
+	}
+	checkIndex(t)
+	checkFilters(t)
+}
+
+func TestIndexAfterEvict(t *testing.T) {
+	setSettings(t, map[string]string{"node_evict_fail_count": "3"})
+	seedPool(300, 200, 5)
+	aliveRunFailed.Store(false)
+	poolMutex.Lock()
+	for i := range pool {
+		if i%3 == 0 {
+			pool[i].Info.ConsecutiveFail = 5
+		}
+	}
+	poolMutex.Unlock()
+	if evicted := Evict(); evicted != 67 {
+		t.Fatalf("evicted %d nodes, want 67", evicted)
+	}
+	checkIndex(t)
+	checkFilters(t)
+}
+
+func TestIndexAfterResize(t *testing.T) {
+	seedPool(300, 300, 5)
+	if result := Resize(120); result.Count != 120 || result.Evicted != 180 {
+		t.Fatalf("shrink result %+v", result)
+	}
+	checkIndex(t)
+	checkFilters(t)
+	if result := Resize(500); result.Count != 120 || cap(pool) != 500 {
+		t.Fatalf("grow result %+v", result)
+	}
+	checkIndex(t)
+	checkFilters(t)
+}
+
+func TestIndexAfterUpdateInfo(t *testing.T) {
+	seedPool(300, 200, 5)
+	r := rand.New(rand.NewSource(3))
+	for key := uint64(1); key <= 200; key += 2 {
+		UpdateInfo(key, func(info *nodeModel.Info) {
+			info.Country = testCountries[r.Intn(len(testCountries))]
+			info.SetAliveStatus(nodeModel.Alive, r.Intn(2) == 0)
+			info.SetAliveStatus(nodeModel.TikTok, r.Intn(2) == 0)
+			info.SetCapability(testCapabilities[r.Intn(len(testCapabilities))], r.Intn(2) == 0)
+		})
+	}
+	checkIndex(t)
+	checkFilters(t)
+}
+
+const benchPoolSize = 50000
+
+var benchFilter = nodeModel.Filter{SubId: []uint16{7}, AliveStatus: nodeModel.Alive}
+
+func BenchmarkGetByFilter(b *testing.B) {
+	seedPool(benchPoolSize, benchPoolSize, 100)
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			GetByFilter(benchFilter)
+		}
+	})
+	b.Run("linear", func(b *testing.B) {
+		for b.Loop() {
+			scanByFilter(benchFilter)
+		}
+	})
+}
+
+func BenchmarkGetBySubId(b *testing.B) {
+	seedPool(benchPoolSize, benchPoolSize, 100)
+	subs := []uint16{7, 42}
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			GetBySubId(subs)
+		}
+	})
+	b.Run("linear", func(b *testing.B) {
+		for b.Loop() {
+			scanBySubId(subs)
+		}
+	})
+}
+
+// BenchmarkRefreshInfo 汇总统计需要遍历整个节点池, 不使用索引, 只测量 50k 节点下的耗时
+func BenchmarkRefreshInfo(b *testing.B) {
+	seedPool(benchPoolSize, benchPoolSize, 100)
+	for b.Loop() {
+		RefreshInfo()
+	}
+}
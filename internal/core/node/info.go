@@ -20,10 +20,16 @@ func RefreshInfo() {
 			s = &infoSums{}
 			subAggBuf[n.Base.SubId] = s
 		}
+		speedUp := uint64(n.Info.SpeedUp.Average())
+		speedDown := uint64(n.Info.SpeedDown.Average())
+		delay := uint64(n.Info.Delay.Average())
 		s.count++
-		s.sumSpeedUp += uint64(n.Info.SpeedUp.Average())
-		s.sumSpeedDown += uint64(n.Info.SpeedDown.Average())
-		s.sumDelay += uint64(n.Info.Delay.Average())
+		if n.Info.AliveStatus&nodeModel.Alive != 0 {
+			s.alive++
+		}
+		s.sumSpeedUp += speedUp
+		s.sumSpeedDown += speedDown
+		s.sumDelay += delay
 		s.sumRisk += uint64(n.Info.Risk)
 
 		c := countryAggBuf[n.Info.Country]
@@ -32,9 +38,9 @@ func RefreshInfo() {
 			countryAggBuf[n.Info.Country] = c
 		}
 		c.count++
-		c.sumSpeedUp += uint64(n.Info.SpeedUp.Average())
-		c.sumSpeedDown += uint64(n.Info.SpeedDown.Average())
-		c.sumDelay += uint64(n.Info.Delay.Average())
+		c.sumSpeedUp += speedUp
+		c.sumSpeedDown += speedDown
+		c.sumDelay += delay
 		c.sumRisk += uint64(n.Info.Risk)
 	}
 	poolMutex.RUnlock()
@@ -52,6 +58,7 @@ func RefreshInfo() {
 		}
 		subInfoMap[subID] = nodeModel.SimpleInfo{
 			Count:     s.count,
+			Alive:     s.alive,
 			SpeedUp:   uint32(s.sumSpeedUp / uint64(s.count)),
 			SpeedDown: uint32(s.sumSpeedDown / uint64(s.count)),
 			Delay:     uint16(s.sumDelay / uint64(s.count)),
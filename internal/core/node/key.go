@@ -0,0 +1,337 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/cespare/xxhash/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KeyPresetDefault = "default"
+	KeyPresetStrict  = "strict"
+	KeyPresetLoose   = "loose"
+	KeyPresetIP      = "ip"
+)
+
+// resolvedIP 虚拟字段, 取 server 解析后的 IP, 解析失败时使用 server 本身
+const resolvedIP = "resolved-ip"
+
+// keyPresets 去重方式对应的字段, default 沿用 UniqueKey 以保持已有节点的唯一键不变
+var keyPresets = map[string][]string{
+	KeyPresetDefault: nil,
+	KeyPresetStrict: {
+		"type", "server", "servername", "sni", "port", "uuid", "username", "password",
+		"cipher", "alterId", "flow", "network", "tls",
+		"ws-opts", "grpc-opts", "h2-opts", "http-opts", "reality-opts",
+		"plugin", "plugin-opts", "obfs", "obfs-password", "protocol", "protocol-param",
+	},
+	KeyPresetLoose: {"type", "server", "port"},
+	KeyPresetIP:    {"type", resolvedIP, "port", "uuid", "username", "password"},
+}
+
+// keyFieldNames mihomo 节点配置中可用于去重的顶层字段
+var keyFieldNames = map[string]bool{
+	"name": true, "type": true, "server": true, "port": true, "ports": true, resolvedIP: true,
+	"uuid": true, "username": true, "password": true, "auth": true, "auth-str": true, "token": true,
+	"cipher": true, "alterId": true, "flow": true, "network": true, "tls": true, "udp": true,
+	"sni": true, "servername": true, "alpn": true, "fingerprint": true, "client-fingerprint": true,
+	"skip-cert-verify": true, "packet-encoding": true, "version": true, "psk": true,
+	"plugin": true, "obfs": true, "obfs-password": true, "protocol": true, "protocol-param": true,
+	"up": true, "down": true, "congestion-controller": true,
+	"private-key": true, "public-key": true, "pre-shared-key": true, "ip": true, "ipv6": true, "reserved": true, "mtu": true,
+}
+
+// keyOptsNames 可以用 ws-opts.path 形式引用子字段的对象字段
+var keyOptsNames = map[string]bool{
+	"ws-opts": true, "grpc-opts": true, "h2-opts": true, "http-opts": true, "reality-opts": true,
+	"plugin-opts": true, "smux": true, "shadow-tls-opts": true, "ech-opts": true,
+}
+
+// knownKeyField 字段为已知的顶层字段, 或以已知对象字段开头的嵌套字段
+func knownKeyField(field string) bool {
+	top, _, nested := strings.Cut(field, ".")
+	if keyOptsNames[top] {
+		return true
+	}
+	return !nested && keyFieldNames[top]
+}
+
+// parseKeyFields 解析以逗号分隔的字段列表, 返回其中的已知字段与未知字段
+func parseKeyFields(config string) (known, unknown []string) {
+	for _, field := range strings.Split(config, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		if knownKeyField(field) {
+			known = append(known, field)
+		} else {
+			unknown = append(unknown, field)
+		}
+	}
+	return known, unknown
+}
+
+// CheckDedupeKey 校验 NODE_DEDUPE_KEY, 只接受预设名称或由已知字段组成的列表
+func CheckDedupeKey(config string) error {
+	config = strings.TrimSpace(config)
+	if _, ok := keyPresets[config]; ok || config == "" {
+		return nil
+	}
+	known, unknown := parseKeyFields(config)
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown dedupe key field: %s", strings.Join(unknown, ", "))
+	}
+	if len(known) == 0 {
+		return fmt.Errorf("dedupe key must be a preset or a list of fields")
+	}
+	return nil
+}
+
+var (
+	keyMutex  sync.Mutex
+	keyConfig string
+	keyFields []string
+)
+
+// currentKeyFields 返回当前去重方式使用的字段, nil 表示使用 default
+// NODE_DEDUPE_KEY 为预设名称或以逗号分隔的字段列表, 嵌套字段使用 ws-opts.path 的形式
+func currentKeyFields() []string {
+	config := strings.TrimSpace(op.GetSettingStr(setting.NODE_DEDUPE_KEY))
+	keyMutex.Lock()
+	defer keyMutex.Unlock()
+	if config == keyConfig {
+		return keyFields
+	}
+	keyConfig = config
+	if fields, ok := keyPresets[config]; ok || config == "" {
+		keyFields = fields
+		return keyFields
+	}
+	// 未知字段在所有节点上都为空, 只剩未知字段时全部节点会得到同一个唯一键
+	known, unknown := parseKeyFields(config)
+	if len(unknown) > 0 {
+		log.Warnf("unknown dedupe key field %v ignored", unknown)
+	}
+	if len(known) == 0 {
+		log.Warnf("dedupe key %q has no known field, fallback to default", config)
+	}
+	keyFields = known
+	return keyFields
+}
+
+// GenKey 按 NODE_DEDUPE_KEY 计算节点唯一键, raw 为节点的 json 配置
+func GenKey(raw []byte) uint64 {
+	fields := currentKeyFields()
+	if fields == nil {
+		var unique nodeModel.UniqueKey
+		yaml.Unmarshal(raw, &unique)
+		return unique.Gen()
+	}
+	var proxy map[string]any
+	if err := json.Unmarshal(raw, &proxy); err != nil {
+		return xxhash.Sum64(raw)
+	}
+	var buf bytes.Buffer
+	for _, field := range fields {
+		buf.WriteString(field)
+		buf.WriteByte('=')
+		if field == resolvedIP {
+			server, _ := proxy["server"].(string)
+			buf.WriteString(resolve(server))
+		} else {
+			buf.WriteString(keyValue(lookup(proxy, field)))
+		}
+		buf.WriteByte(0)
+	}
+	return xxhash.Sum64(buf.Bytes())
+}
+
+// GenKeys 批量计算唯一键, 去重字段包含解析 IP 时先并发解析全部域名, 避免逐个节点串行等待 DNS
+func GenKeys(raws [][]byte) []uint64 {
+	if slices.Contains(currentKeyFields(), resolvedIP) {
+		servers := make(map[string]struct{})
+		for _, raw := range raws {
+			var proxy struct {
+				Server string `json:"server"`
+			}
+			if json.Unmarshal(raw, &proxy) == nil {
+				servers[proxy.Server] = struct{}{}
+			}
+		}
+		resolveAll(servers)
+	}
+	keys := make([]uint64, len(raws))
+	for i, raw := range raws {
+		keys[i] = GenKey(raw)
+	}
+	return keys
+}
+
+func lookup(proxy map[string]any, path string) any {
+	var value any = proxy
+	for _, part := range strings.Split(path, ".") {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[part]
+	}
+	return value
+}
+
+func keyValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+const (
+	resolveTimeout = 3 * time.Second
+	resolveTTL     = 30 * time.Minute
+)
+
+type resolved struct {
+	ip     string
+	expire time.Time
+}
+
+var (
+	resolveMutex sync.Mutex
+	resolveCache = make(map[string]resolved)
+)
+
+// resolve 解析域名并返回最小的 IP, 同一地址的多个域名因此得到相同的结果
+func resolve(server string) string {
+	if server == "" || net.ParseIP(server) != nil {
+		return server
+	}
+	now := time.Now()
+	resolveMutex.Lock()
+	if r, ok := resolveCache[server]; ok && now.Before(r.expire) {
+		resolveMutex.Unlock()
+		return r.ip
+	}
+	resolveMutex.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	ip := server
+	if addrs, err := net.DefaultResolver.LookupHost(ctx, server); err == nil && len(addrs) > 0 {
+		ip = slices.Min(addrs)
+	}
+	resolveMutex.Lock()
+	resolveCache[server] = resolved{ip: ip, expire: now.Add(resolveTTL)}
+	resolveMutex.Unlock()
+	return ip
+}
+
+// resolveThreads 批量解析域名时的并发数
+const resolveThreads = 16
+
+// resolveAll 并发解析域名并写入缓存
+func resolveAll(servers map[string]struct{}) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, resolveThreads)
+	for server := range servers {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			resolve(server)
+		}()
+	}
+	wg.Wait()
+}
+
+// Rekey 按当前去重方式重新计算节点池中全部节点的唯一键, 唯一键相同的节点只保留排名靠前的一个
+// 之后各订阅下次更新时会重新解析全部节点
+func Rekey() nodeModel.Rekey {
+	poolMutex.RLock()
+	oldKeys := make([]uint64, len(pool))
+	raws := make([][]byte, len(pool))
+	for i := range pool {
+		oldKeys[i] = pool[i].UniqueKey
+		raws[i] = pool[i].Raw
+	}
+	poolMutex.RUnlock()
+
+	// 计算唯一键可能需要解析域名, 不能持有节点池的锁
+	newKeys := make(map[uint64]uint64, len(raws))
+	for i, key := range GenKeys(raws) {
+		newKeys[oldKeys[i]] = key
+	}
+
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	poolMutex.Lock()
+	result := nodeModel.Rekey{Config: op.GetSettingStr(setting.NODE_DEDUPE_KEY)}
+	seen := make(map[uint64]struct{}, len(pool))
+	var removed []uint64
+	var changed []nodeModel.Data
+	moved := make(map[uint64]uint64)
+	kept := pool[:0]
+	for _, n := range pool {
+		// 计算期间新加入的节点已按新的去重方式生成唯一键
+		key, ok := newKeys[n.UniqueKey]
+		if !ok {
+			key = n.UniqueKey
+		}
+		if _, dup := seen[key]; dup {
+			nodeExist.Remove(n.UniqueKey)
+			removed = append(removed, n.UniqueKey)
+			result.Merged++
+			continue
+		}
+		seen[key] = struct{}{}
+		if key != n.UniqueKey {
+			nodeExist.Remove(n.UniqueKey)
+			nodeExist.Add(key)
+			removed = append(removed, n.UniqueKey)
+			moved[n.UniqueKey] = key
+			n.UniqueKey = key
+			changed = append(changed, snapshot(n))
+			result.Changed++
+		}
+		kept = append(kept, n)
+	}
+	pool = kept
+	reindexLocked()
+	result.Count = len(pool)
+	poolMutex.Unlock()
+
+	persist(changed, removed)
+	ResetOffers()
+	rekeyMarks()
+	rekeyLabels(moved)
+	Relabel()
+	RefreshInfo()
+	log.Infof("node keys recomputed with %q, %d changed, %d merged", result.Config, result.Changed, result.Merged)
+	return result
+}
@@ -0,0 +1,41 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/bestruirui/bestsub/internal/models/setting"
+)
+
+func TestCheckDedupeKey(t *testing.T) {
+	tests := []struct {
+		config string
+		ok     bool
+	}{
+		{"", true},
+		{KeyPresetStrict, true},
+		{KeyPresetIP, true},
+		{"type,server,port", true},
+		{"type, server, ws-opts.path, ws-opts.headers.Host", true},
+		{"strickt", false},
+		{"type,serverr", false},
+		{"server.port", false},
+		{",", false},
+	}
+	for _, tt := range tests {
+		if err := CheckDedupeKey(tt.config); (err == nil) != tt.ok {
+			t.Errorf("CheckDedupeKey(%q) = %v, want ok %v", tt.config, err, tt.ok)
+		}
+	}
+}
+
+func TestUnknownDedupeKeyFallsBack(t *testing.T) {
+	a := []byte(`{"name":"a","type":"ss","server":"1.1.1.1","port":1,"password":"x"}`)
+	b := []byte(`{"name":"b","type":"ss","server":"2.2.2.2","port":2,"password":"y"}`)
+	setSettings(t, map[string]string{setting.NODE_DEDUPE_KEY: "strickt"})
+	if currentKeyFields() != nil {
+		t.Fatalf("unknown preset used fields %v", currentKeyFields())
+	}
+	if GenKey(a) == GenKey(b) {
+		t.Fatal("different nodes share a key")
+	}
+}
@@ -0,0 +1,247 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+var (
+	labelMutex   sync.RWMutex
+	manualLabels = make(map[uint64][]string)
+
+	ruleMutex  sync.Mutex
+	ruleConfig string
+	rules      []labelRule
+)
+
+type labelRule struct {
+	label string
+	field string
+	match *regexp.Regexp
+}
+
+// CheckLabelRules 校验标签规则配置
+func CheckLabelRules(config string) error {
+	_, err := parseLabelRules(config)
+	return err
+}
+
+// parseLabelRules 解析 NODE_LABEL_RULES, 字段或正则无效时返回错误
+func parseLabelRules(config string) ([]labelRule, error) {
+	var raw []nodeModel.LabelRule
+	if err := json.Unmarshal([]byte(config), &raw); err != nil {
+		return nil, err
+	}
+	result := make([]labelRule, 0, len(raw))
+	for _, r := range raw {
+		if r.Label == "" {
+			return nil, fmt.Errorf("label rule missing label")
+		}
+		switch r.Field {
+		case nodeModel.LabelFieldName, nodeModel.LabelFieldServer, nodeModel.LabelFieldType,
+			nodeModel.LabelFieldSubName, nodeModel.LabelFieldSubTag:
+		default:
+			return nil, fmt.Errorf("label rule %s: unknown field %q", r.Label, r.Field)
+		}
+		match, err := regexp.Compile(r.Match)
+		if err != nil {
+			return nil, fmt.Errorf("label rule %s: %w", r.Label, err)
+		}
+		result = append(result, labelRule{label: r.Label, field: r.Field, match: match})
+	}
+	return result, nil
+}
+
+func currentRules() []labelRule {
+	config := op.GetSettingStr(setting.NODE_LABEL_RULES)
+	ruleMutex.Lock()
+	defer ruleMutex.Unlock()
+	if config == ruleConfig {
+		return rules
+	}
+	ruleConfig = config
+	parsed, err := parseLabelRules(config)
+	if err != nil {
+		log.Warnf("invalid node label rules: %v", err)
+	}
+	rules = parsed
+	return rules
+}
+
+func loadLabels() {
+	labels, err := op.GetNodeLabelList(context.Background())
+	if err != nil {
+		log.Warnf("load node labels failed: %v", err)
+		return
+	}
+	labelMutex.Lock()
+	defer labelMutex.Unlock()
+	clear(manualLabels)
+	for _, l := range labels {
+		manualLabels[l.Key] = append(manualLabels[l.Key], l.Label)
+	}
+}
+
+// AddLabels 为节点手动添加标签
+func AddLabels(ctx context.Context, key uint64, labels []string) error {
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+		if err := op.CreateNodeLabel(ctx, &nodeModel.Label{Key: key, Label: label}); err != nil {
+			return err
+		}
+		labelMutex.Lock()
+		if !slices.Contains(manualLabels[key], label) {
+			manualLabels[key] = append(manualLabels[key], label)
+		}
+		labelMutex.Unlock()
+	}
+	Relabel()
+	return nil
+}
+
+// RemoveLabel 删除节点的手动标签, 规则添加的标签需修改规则
+func RemoveLabel(ctx context.Context, key uint64, label string) error {
+	if err := op.DeleteNodeLabel(ctx, key, label); err != nil {
+		return err
+	}
+	labelMutex.Lock()
+	manualLabels[key] = slices.DeleteFunc(slices.Clone(manualLabels[key]), func(l string) bool { return l == label })
+	if len(manualLabels[key]) == 0 {
+		delete(manualLabels, key)
+	}
+	labelMutex.Unlock()
+	Relabel()
+	return nil
+}
+
+// Relabel 按手动标签与当前规则重新计算节点池中全部节点的标签
+// 标签规则或订阅名称、标签变化后调用
+func Relabel() {
+	// persistMutex 保证计算期间节点池成员不变
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	poolMutex.RLock()
+	nodes := slices.Clone(pool)
+	poolMutex.RUnlock()
+	labels := resolveLabels(nodes)
+	poolMutex.Lock()
+	relabelLocked(labels)
+	poolMutex.Unlock()
+}
+
+// relabelLocked 写入 resolveLabels 计算的标签并重建索引, 调用方需持有 poolMutex 写锁
+func relabelLocked(labels map[uint64][]string) {
+	for i := range pool {
+		pool[i].Labels = labels[pool[i].UniqueKey]
+	}
+	reindexLocked()
+}
+
+// resolveLabels 计算节点的标签, 需要查询订阅信息并解析节点, 调用方不能持有 poolMutex
+func resolveLabels(nodes []nodeModel.Data) map[uint64][]string {
+	rules := currentRules()
+	subs := make(map[uint16]*labelSub)
+	result := make(map[uint64][]string, len(nodes))
+	labelMutex.RLock()
+	defer labelMutex.RUnlock()
+	for i := range nodes {
+		n := &nodes[i]
+		var labels []string
+		labels = append(labels, manualLabels[n.UniqueKey]...)
+		if len(rules) > 0 {
+			fields := n.Fields()
+			sub := subs[n.SubId]
+			if sub == nil {
+				sub = &labelSub{
+					name: op.GetSubNameByID(context.Background(), n.SubId),
+					tags: op.GetSubTagsByID(context.Background(), n.SubId),
+				}
+				subs[n.SubId] = sub
+			}
+			for _, r := range rules {
+				if !slices.Contains(labels, r.label) && r.matchNode(&fields, sub) {
+					labels = append(labels, r.label)
+				}
+			}
+		}
+		slices.Sort(labels)
+		result[n.UniqueKey] = slices.Compact(labels)
+	}
+	return result
+}
+
+type labelSub struct {
+	name string
+	tags []string
+}
+
+func (r *labelRule) matchNode(fields *nodeModel.Fields, sub *labelSub) bool {
+	switch r.field {
+	case nodeModel.LabelFieldName:
+		return r.match.MatchString(fields.Name)
+	case nodeModel.LabelFieldServer:
+		return r.match.MatchString(fields.Server)
+	case nodeModel.LabelFieldType:
+		return r.match.MatchString(fields.Type)
+	case nodeModel.LabelFieldSubName:
+		return r.match.MatchString(sub.name)
+	case nodeModel.LabelFieldSubTag:
+		return slices.ContainsFunc(sub.tags, r.match.MatchString)
+	}
+	return false
+}
+
+// GetLabels 返回节点池中出现的全部标签及节点数
+func GetLabels() []nodeModel.LabelCount {
+	poolMutex.RLock()
+	result := make([]nodeModel.LabelCount, 0, len(index.label))
+	for label, list := range index.label {
+		result = append(result, nodeModel.LabelCount{Label: label, Count: len(list)})
+	}
+	poolMutex.RUnlock()
+	sort.Slice(result, func(i, j int) bool { return result[i].Label < result[j].Label })
+	return result
+}
+
+// rekeyLabels 唯一键变化后迁移手动标签, 调用方不能持有 labelMutex
+func rekeyLabels(changed map[uint64]uint64) {
+	ctx := context.Background()
+	labelMutex.Lock()
+	defer labelMutex.Unlock()
+	moved := make(map[uint64][]string, len(changed))
+	for oldKey, newKey := range changed {
+		labels, ok := manualLabels[oldKey]
+		if !ok {
+			continue
+		}
+		for _, label := range labels {
+			if err := op.DeleteNodeLabel(ctx, oldKey, label); err != nil {
+				log.Warnf("rekey node label failed: %v", err)
+			}
+		}
+		delete(manualLabels, oldKey)
+		moved[newKey] = labels
+	}
+	for key, labels := range moved {
+		for _, label := range labels {
+			if err := op.CreateNodeLabel(ctx, &nodeModel.Label{Key: key, Label: label}); err != nil {
+				log.Warnf("rekey node label failed: %v", err)
+			}
+		}
+		manualLabels[key] = labels
+	}
+}
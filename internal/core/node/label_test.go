@@ -0,0 +1,34 @@
+package node
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+)
+
+// TestRelabel 规则标签在 Relabel 与合并新节点时计算, 并同步到索引
+func TestRelabel(t *testing.T) {
+	seedPool(20, 10, 2)
+	setSettings(t, map[string]string{
+		setting.NODE_LABEL_RULES: `[{"label":"odd","field":"name","match":"[13579]$"},{"label":"ss","field":"type","match":"^ss$"}]`,
+	})
+	Relabel()
+
+	r := rand.New(rand.NewSource(7))
+	if added := mergeNodesToPool([]nodeModel.Data{testNode(r, 101, 2), testNode(r, 102, 2)}); added != 2 {
+		t.Fatalf("merged %d nodes, want 2", added)
+	}
+	for _, n := range GetAll() {
+		want := []string{"ss"}
+		if n.UniqueKey%2 == 1 {
+			want = []string{"odd", "ss"}
+		}
+		if !slices.Equal(n.Labels, want) {
+			t.Errorf("node %d labels %v, want %v", n.UniqueKey, n.Labels, want)
+		}
+	}
+	checkIndex(t)
+}
@@ -0,0 +1,95 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bestruirui/bestsub/internal/database"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+)
+
+// TestMain 使用临时 sqlite 数据库, 节点池的持久化与设置读取走真实实现
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bestsub-node")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	database.Initialize("sqlite", filepath.Join(dir, "bestsub.db"))
+	code := m.Run()
+	database.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+var (
+	testCountries    = []string{"US", "JP", "HK", "SG", "DE", "GB", "FR", "KR"}
+	testCapabilities = []string{"chatgpt", "netflix", "youtube"}
+	testLabels       = []string{"fast", "stable"}
+)
+
+// testNode 生成一个属性随机但可复现的节点
+func testNode(r *rand.Rand, key uint64, subs int) nodeModel.Data {
+	info := &nodeModel.Info{
+		Country:     testCountries[r.Intn(len(testCountries))],
+		AliveStatus: r.Uint64() & 0xf,
+		Risk:        uint8(r.Intn(100)),
+		AddedAt:     r.Int63n(1 << 30),
+	}
+	info.Delay.Update(uint16(r.Intn(2000)))
+	info.SpeedDown.Update(uint32(r.Intn(100000)))
+	for _, c := range testCapabilities {
+		info.SetCapability(c, r.Intn(3) == 0)
+	}
+	var labels []string
+	for _, label := range testLabels {
+		if r.Intn(4) == 0 {
+			labels = append(labels, label)
+		}
+	}
+	return nodeModel.Data{
+		Base: nodeModel.Base{
+			Raw:       fmt.Appendf(nil, `{"name":"n%d","type":"ss","server":"10.0.%d.%d","port":443}`, key, key>>8&0xff, key&0xff),
+			SubId:     uint16(r.Intn(subs) + 1),
+			UniqueKey: key,
+		},
+		Info:   info,
+		Labels: labels,
+	}
+}
+
+// seedPool 直接填充容量为 size 的节点池, 不经过网络测试与数据库
+func seedPool(size, count, subs int) {
+	r := rand.New(rand.NewSource(1))
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	pool = make([]nodeModel.Data, 0, size)
+	nodeExist = NewExist(size)
+	nodeProcess = NewExist(size)
+	for i := range count {
+		n := testNode(r, uint64(i+1), subs)
+		pool = append(pool, n)
+		nodeExist.Add(n.UniqueKey)
+	}
+	reindexLocked()
+}
+
+// setSettings 修改设置并在测试结束后恢复
+func setSettings(t *testing.T, values map[string]string) {
+	t.Helper()
+	var changed, restore []setting.Setting
+	for key, value := range values {
+		restore = append(restore, setting.Setting{Key: key, Value: op.GetSettingStr(key)})
+		changed = append(changed, setting.Setting{Key: key, Value: value})
+	}
+	if err := op.UpdateSetting(context.Background(), &changed); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { op.UpdateSetting(context.Background(), &restore) })
+}
@@ -0,0 +1,199 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// ErrPinFull 固定节点数量已达到节点池容量
+var ErrPinFull = errors.New("pinned nodes already fill the pool")
+
+var (
+	markMutex sync.RWMutex
+	pinned    = make(map[uint64]struct{})
+	blacklist = make(map[uint64]struct{})
+)
+
+func loadMarks() {
+	marks, err := op.GetNodeMarkList(context.Background())
+	if err != nil {
+		log.Warnf("load node marks failed: %v", err)
+		return
+	}
+	markMutex.Lock()
+	defer markMutex.Unlock()
+	clear(pinned)
+	clear(blacklist)
+	for _, mark := range marks {
+		switch mark.Type {
+		case nodeModel.MarkPin:
+			pinned[mark.Key] = struct{}{}
+		case nodeModel.MarkBlacklist:
+			blacklist[mark.Key] = struct{}{}
+		}
+	}
+}
+
+func IsPinned(key uint64) bool {
+	markMutex.RLock()
+	defer markMutex.RUnlock()
+	_, ok := pinned[key]
+	return ok
+}
+
+func IsBlacklisted(key uint64) bool {
+	markMutex.RLock()
+	defer markMutex.RUnlock()
+	_, ok := blacklist[key]
+	return ok
+}
+
+// PinnedCount 返回固定节点的数量
+func PinnedCount() int {
+	markMutex.RLock()
+	defer markMutex.RUnlock()
+	return len(pinned)
+}
+
+// Pin 固定节点池中的节点, 固定后不会被淘汰
+// 固定节点已占满节点池时拒绝, 否则排名时超出容量的固定节点仍会被移出
+func Pin(ctx context.Context, key uint64) error {
+	n, ok := GetByKey(key)
+	if !ok {
+		return fmt.Errorf("node not found")
+	}
+	poolMutex.RLock()
+	size := cap(pool)
+	poolMutex.RUnlock()
+	markMutex.Lock()
+	defer markMutex.Unlock()
+	if _, ok := pinned[key]; ok {
+		return nil
+	}
+	if len(pinned) >= size {
+		return ErrPinFull
+	}
+	if err := op.CreateNodeMark(ctx, &nodeModel.Mark{Key: key, Type: nodeModel.MarkPin, Name: n.Fields().Name, Raw: n.Raw}); err != nil {
+		return err
+	}
+	pinned[key] = struct{}{}
+	return nil
+}
+
+func Unpin(ctx context.Context, key uint64) error {
+	if err := op.DeleteNodeMark(ctx, key, nodeModel.MarkPin); err != nil {
+		return err
+	}
+	markMutex.Lock()
+	delete(pinned, key)
+	markMutex.Unlock()
+	return nil
+}
+
+// Blacklist 将节点加入黑名单并移出节点池, 之后不会再加入节点池
+func Blacklist(ctx context.Context, key uint64) error {
+	mark := nodeModel.Mark{Key: key, Type: nodeModel.MarkBlacklist}
+	if n, ok := GetByKey(key); ok {
+		mark.Name = n.Fields().Name
+		mark.Raw = n.Raw
+	}
+	if err := op.CreateNodeMark(ctx, &mark); err != nil {
+		return err
+	}
+	if err := Unpin(ctx, key); err != nil {
+		return err
+	}
+	markMutex.Lock()
+	blacklist[key] = struct{}{}
+	markMutex.Unlock()
+	Delete(key)
+	return nil
+}
+
+func Unblacklist(ctx context.Context, key uint64) error {
+	if err := op.DeleteNodeMark(ctx, key, nodeModel.MarkBlacklist); err != nil {
+		return err
+	}
+	markMutex.Lock()
+	delete(blacklist, key)
+	markMutex.Unlock()
+	return nil
+}
+
+// rekeyMarks 去重方式变化后按保存的节点配置重新计算标记的唯一键, 未保存配置的标记保持不变
+func rekeyMarks() {
+	ctx := context.Background()
+	marks, err := op.GetNodeMarkList(ctx)
+	if err != nil {
+		log.Warnf("load node marks failed: %v", err)
+		return
+	}
+	raws := make([][]byte, len(marks))
+	for i := range marks {
+		raws[i] = marks[i].Raw
+	}
+	keys := GenKeys(raws)
+	for i, mark := range marks {
+		if len(mark.Raw) == 0 {
+			continue
+		}
+		key := keys[i]
+		if key == mark.Key {
+			continue
+		}
+		if err := op.DeleteNodeMark(ctx, mark.Key, mark.Type); err != nil {
+			log.Warnf("rekey node mark failed: %v", err)
+			continue
+		}
+		mark.Key = key
+		if err := op.CreateNodeMark(ctx, &mark); err != nil {
+			log.Warnf("rekey node mark failed: %v", err)
+		}
+	}
+	loadMarks()
+}
+
+// Delete 将单个节点移出节点池, 返回节点是否存在
+func Delete(key uint64) bool {
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	poolMutex.Lock()
+	i := indexLocked(key)
+	if i < 0 {
+		poolMutex.Unlock()
+		return false
+	}
+	nodeExist.Remove(key)
+	pool = append(pool[:i], pool[i+1:]...)
+	reindexLocked()
+	poolMutex.Unlock()
+
+	persist(nil, []uint64{key})
+	return true
+}
+
+// pinnedFirst 将固定的节点稳定地移到最前, 保证截取前 N 个时不会被淘汰
+func pinnedFirst(nodes []nodeModel.Data) {
+	markMutex.RLock()
+	defer markMutex.RUnlock()
+	if len(pinned) == 0 {
+		return
+	}
+	head := make([]nodeModel.Data, 0, len(pinned))
+	tail := make([]nodeModel.Data, 0, len(nodes))
+	for _, n := range nodes {
+		if _, ok := pinned[n.UniqueKey]; ok {
+			head = append(head, n)
+		} else {
+			tail = append(tail, n)
+		}
+	}
+	copy(nodes, head)
+	copy(nodes[len(head):], tail)
+}
@@ -0,0 +1,38 @@
+package node
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// TestBlacklistDuringCheck 节点仍在测试中时加入黑名单, 测试结束后不会合并进节点池
+func TestBlacklistDuringCheck(t *testing.T) {
+	seedPool(10, 5, 2)
+	ctx := context.Background()
+	r := rand.New(rand.NewSource(5))
+	checking := testNode(r, 100, 2)
+	other := testNode(r, 101, 2)
+	nodeProcess.Add(checking.UniqueKey)
+
+	if err := Blacklist(ctx, checking.UniqueKey); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Unblacklist(ctx, checking.UniqueKey) })
+
+	if added := mergeNodesToPool([]nodeModel.Data{checking, other}); added != 1 {
+		t.Fatalf("merged %d nodes, want 1", added)
+	}
+	if _, ok := GetByKey(checking.UniqueKey); ok {
+		t.Fatal("blacklisted node merged into the pool")
+	}
+	if _, ok := GetByKey(other.UniqueKey); !ok {
+		t.Fatal("node missing from the pool")
+	}
+	if nodeExist.Exist(checking.UniqueKey) {
+		t.Fatal("blacklisted node marked as existing")
+	}
+	checkIndex(t)
+}
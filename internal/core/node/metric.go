@@ -0,0 +1,34 @@
+package node
+
+import nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+
+// UpdateInfo 在节点池锁内修改节点的测试信息, 节点已移出节点池时返回 false
+// 网络测试应在调用前完成, fn 中只做内存修改
+func UpdateInfo(key uint64, fn func(info *nodeModel.Info)) bool {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	i := indexLocked(key)
+	if i < 0 {
+		return false
+	}
+	info := pool[i].Info
+	oldCountry, oldStatus, oldCapabilities := info.Country, info.AliveStatus, info.Capabilities
+	fn(info)
+	index.moveLocked(i, oldCountry, oldStatus, oldCapabilities, info)
+	return true
+}
+
+// snapshot 复制节点的测试信息, 使调用方在锁外读取时不与检测任务竞争
+func snapshot(n nodeModel.Data) nodeModel.Data {
+	n.Info = n.Info.Clone()
+	return n
+}
+
+// snapshotAll 复制节点池, 调用方需持有 poolMutex
+func snapshotAll() []nodeModel.Data {
+	nodes := make([]nodeModel.Data, len(pool))
+	for i := range pool {
+		nodes[i] = snapshot(pool[i])
+	}
+	return nodes
+}
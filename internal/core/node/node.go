@@ -1,19 +1,13 @@
 package node
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
 	"net/http"
-	"os"
-	"path"
 	"slices"
-	"sort"
 	"time"
 
 	"gopkg.in/yaml.v3"
 
-	"github.com/bestruirui/bestsub/internal/config"
 	"github.com/bestruirui/bestsub/internal/core/mihomo"
 	"github.com/bestruirui/bestsub/internal/core/task"
 	"github.com/bestruirui/bestsub/internal/database/op"
@@ -22,53 +16,6 @@ import (
 	"github.com/bestruirui/bestsub/internal/utils/log"
 )
 
-func InitNodePool(size int) {
-	pool = make([]nodeModel.Data, 0, size)
-	nodeExist = NewExist(size)
-	nodeProcess = NewExist(size)
-	sessionFile := config.Base().Session.NodePath
-	if _, err := os.Stat(sessionFile); os.IsNotExist(err) {
-		return
-	}
-
-	file, err := os.Open(sessionFile)
-	if err != nil {
-		return
-	}
-	defer file.Close()
-
-	decoder := gob.NewDecoder(file)
-	if err := decoder.Decode(&pool); err != nil {
-		log.Warnf("restore node pool failed: %v", err)
-		os.Remove(sessionFile)
-		return
-	}
-	for _, node := range pool {
-		nodeExist.Add(node.Base.UniqueKey)
-	}
-}
-
-func CloseNodePool() error {
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
-	if err := encoder.Encode(pool); err != nil {
-		log.Warnf("save node pool failed: %v", err)
-		return err
-	}
-	filePath := config.Base().Session.NodePath
-
-	dir := path.Dir(filePath)
-	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		os.MkdirAll(dir, 0755)
-	}
-	if os.WriteFile(filePath, buf.Bytes(), 0600) != nil {
-		log.Warnf("node pool save failed")
-	}
-	log.Debugf("node pool saved")
-
-	return nil
-}
-
 type nameNode struct {
 	Name string
 }
@@ -82,6 +29,10 @@ func Add(node *[]nodeModel.Base) int {
 			log.Warnf("yaml.Unmarshal failed: %v", err)
 			continue
 		}
+		if IsBlacklisted(n.UniqueKey) {
+			log.Debugf("node blacklisted: %s", nameNode.Name)
+			continue
+		}
 		if !nodeExist.Exist(n.UniqueKey) && !nodeProcess.Exist(n.UniqueKey) {
 			nodeProcess.Add(n.UniqueKey)
 			nodesToProcess = append(nodesToProcess, n)
@@ -94,10 +45,10 @@ func Add(node *[]nodeModel.Base) int {
 	log.Debugf("add %d nodes to process", len(nodesToProcess))
 
 	if len(nodesToProcess) > 0 {
+		wgSync.Add(len(nodesToProcess))
 		go func() {
 			for _, node := range nodesToProcess {
 				n := node // capture loop variable
-				wgSync.Add(1)
 				task.Submit(func() {
 					defer wgSync.Done()
 					defer nodeProcess.Remove(n.UniqueKey)
@@ -129,7 +80,8 @@ func Add(node *[]nodeModel.Base) int {
 
 					var info nodeModel.Info
 					info.Delay.Update(uint16(time.Since(start).Milliseconds()))
-					info.SetAliveStatus(nodeModel.Alive, true)
+					RecordAlive(&info, true)
+					info.AddedAt = time.Now().Unix()
 					rawCopy := append([]byte(nil), n.Raw...)
 					n.Raw = rawCopy
 					validMutex.Lock()
@@ -143,21 +95,26 @@ func Add(node *[]nodeModel.Base) int {
 
 			}
 		}()
+		validMutex.Lock()
 		if !wgStatus {
 			wgStatus = true
 			go func() {
 				time.Sleep(time.Second * 5)
 				wgSync.Wait()
+				validMutex.Lock()
+				batch := validNodes
+				validNodes = nil
+				wgStatus = false
+				validMutex.Unlock()
 				mergedNodes := 0
-				if len(validNodes) > 0 {
-					mergedNodes = mergeNodesToPool(validNodes)
+				if len(batch) > 0 {
+					mergedNodes = mergeNodesToPool(batch)
 					RefreshInfo()
 				}
 				log.Infof("Receipt successful, %d new nodes added", mergedNodes)
-				validNodes = validNodes[:0]
-				wgStatus = false
 			}()
 		}
+		validMutex.Unlock()
 	}
 	return len(nodesToProcess)
 }
@@ -170,19 +127,20 @@ func ForEach(fn func(node []byte)) {
 	}
 }
 
+// GetAll 返回节点池的快照
 func GetAll() []nodeModel.Data {
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
-	return pool
+	return snapshotAll()
 }
 
 func GetBySubIdExclude(subId []uint16) []uint16 {
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
 	var result []uint16
-	for _, node := range pool {
-		if !slices.Contains(subId, node.Base.SubId) {
-			result = append(result, node.Base.SubId)
+	for id := range index.sub {
+		if !slices.Contains(subId, id) {
+			result = append(result, id)
 		}
 	}
 	return result
@@ -191,102 +149,93 @@ func GetBySubIdExclude(subId []uint16) []uint16 {
 func GetBySubId(subId []uint16) *[]nodeModel.Data {
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
-	var result []nodeModel.Data
-	for _, node := range pool {
-		if slices.Contains(subId, node.Base.SubId) {
-			result = append(result, node)
-		}
+	lists := make([][]int, 0, len(subId))
+	for _, id := range subId {
+		lists = append(lists, index.sub[id])
+	}
+	positions := union(lists)
+	result := make([]nodeModel.Data, 0, len(positions))
+	for _, i := range positions {
+		result = append(result, snapshot(pool[i]))
 	}
 	return &result
 }
 
+// GetByFilter 返回符合过滤条件的节点快照, 保持节点池的排名顺序
 func GetByFilter(filter nodeModel.Filter) *[]nodeModel.Data {
+	m := newMatcher(&filter)
 	poolMutex.RLock()
 	defer poolMutex.RUnlock()
 	var result []nodeModel.Data
-	for _, node := range pool {
-		if len(filter.SubId) > 0 {
-			if filter.SubIdExclude && slices.Contains(filter.SubId, node.Base.SubId) {
-				continue
-			}
-			if !filter.SubIdExclude && !slices.Contains(filter.SubId, node.Base.SubId) {
-				continue
-			}
-		}
-		if filter.AliveStatus != 0 && node.Info.AliveStatus&filter.AliveStatus != filter.AliveStatus {
-			continue
-		}
-		if len(filter.Country) > 0 {
-			if filter.CountryExclude && slices.Contains(filter.Country, node.Info.Country) {
-				continue
+	if positions, ok := index.candidates(m); ok {
+		for _, i := range positions {
+			if m.match(&pool[i]) {
+				result = append(result, snapshot(pool[i]))
 			}
-			if !filter.CountryExclude && !slices.Contains(filter.Country, node.Info.Country) {
-				continue
-			}
-		}
-		if filter.SpeedUpMore != 0 && node.Info.SpeedUp.Average() < filter.SpeedUpMore {
-			continue
-		}
-		if filter.SpeedDownMore != 0 && node.Info.SpeedDown.Average() < filter.SpeedDownMore {
-			continue
 		}
-		if filter.DelayLessThan != 0 && node.Info.Delay.Average() > filter.DelayLessThan {
-			continue
-		}
-		if filter.RiskLessThan != 0 && node.Info.Risk > filter.RiskLessThan {
-			continue
+		return &result
+	}
+	for i := range pool {
+		if m.match(&pool[i]) {
+			result = append(result, snapshot(pool[i]))
 		}
-		result = append(result, node)
 	}
 	return &result
 }
 
+// mergeNodesToPool 将新节点合并到节点池并持久化, 返回加入的节点数
 func mergeNodesToPool(newNodes []nodeModel.Data) int {
-	sort.Slice(newNodes, func(i, j int) bool {
-		return newNodes[i].Info.Delay.Average() < newNodes[j].Info.Delay.Average()
-	})
-
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	// 在 persistMutex 内计算, 避免与 Relabel 交错使用旧规则
+	labels := resolveLabels(newNodes)
+	for i := range newNodes {
+		newNodes[i].Labels = labels[newNodes[i].UniqueKey]
+	}
 	poolMutex.Lock()
-	defer poolMutex.Unlock()
-
-	poolLen := len(pool)
-	poolCap := cap(pool)
+	added, removed := mergeLocked(newNodes)
+	poolMutex.Unlock()
 
-	if poolLen < poolCap {
-		remainingCap := poolCap - poolLen
-		if len(newNodes) < remainingCap {
-			pool = append(pool, newNodes...)
-			for _, node := range newNodes {
-				nodeExist.Add(node.Base.UniqueKey)
-			}
-			return len(newNodes)
-		} else {
-			pool = append(pool, newNodes[:remainingCap]...)
-			for _, node := range newNodes[:remainingCap] {
-				nodeExist.Add(node.Base.UniqueKey)
-			}
-			newNodes = newNodes[remainingCap:]
-		}
-	}
+	persist(added, removed)
+	return len(added)
+}
 
-	sort.Slice(pool, func(i, j int) bool {
-		return pool[i].Info.Delay.Average() < pool[j].Info.Delay.Average()
+// mergeLocked 将新节点与池中节点一起按策略排名, 保留前 cap(pool) 个, 返回加入与移出的节点
+// 新节点的标签需在加锁前由 resolveLabels 计算
+// 测试期间被加入黑名单的新节点不会进入节点池
+func mergeLocked(newNodes []nodeModel.Data) (added []nodeModel.Data, removed []uint64) {
+	newNodes = slices.DeleteFunc(newNodes, func(n nodeModel.Data) bool {
+		return IsBlacklisted(n.UniqueKey)
 	})
+	candidates := make([]nodeModel.Data, 0, len(pool)+len(newNodes))
+	candidates = append(candidates, pool...)
+	candidates = append(candidates, newNodes...)
+	rank(candidates)
+	keep := candidates[:min(cap(pool), len(candidates))]
 
-	newNodeIndex := 0
-	for i := len(pool) - 1; i >= 0 && newNodeIndex < len(newNodes); i-- {
-		if newNodes[newNodeIndex].Info.Delay.Average() < pool[i].Info.Delay.Average() {
-			log.Debugf("new node delay %dms < old delay %dms,merge", newNodes[newNodeIndex].Info.Delay.Average(), pool[i].Info.Delay.Average())
-			nodeExist.Remove(pool[i].Base.UniqueKey)
-			pool[i] = newNodes[newNodeIndex]
-			nodeExist.Add(newNodes[newNodeIndex].Base.UniqueKey)
-			newNodeIndex++
-		} else {
-			log.Debugf("new node delay %dms > old delay %dms,not merge", newNodes[newNodeIndex].Info.Delay.Average(), pool[i].Info.Delay.Average())
-			return newNodeIndex
+	isNew := make(map[uint64]struct{}, len(newNodes))
+	for _, n := range newNodes {
+		isNew[n.UniqueKey] = struct{}{}
+	}
+	kept := make(map[uint64]struct{}, len(keep))
+	for _, n := range keep {
+		kept[n.UniqueKey] = struct{}{}
+		if _, ok := isNew[n.UniqueKey]; ok {
+			nodeExist.Add(n.UniqueKey)
+			added = append(added, snapshot(n))
+		}
+	}
+	for _, n := range pool {
+		if _, ok := kept[n.UniqueKey]; !ok {
+			log.Debugf("node %d evicted, score %d", n.UniqueKey, n.Info.Score)
+			nodeExist.Remove(n.UniqueKey)
+			removed = append(removed, n.UniqueKey)
 		}
 	}
-	return 0
+
+	pool = append(pool[:0], keep...)
+	reindexLocked()
+	return added, removed
 }
 
 func GetSubInfo(subID uint16) nodeModel.SimpleInfo {
@@ -301,19 +250,25 @@ func GetCountryInfo(country string) nodeModel.SimpleInfo {
 	return countryInfoMap[country]
 }
 func DeleteBySubId(subID uint16) {
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
 	poolMutex.Lock()
-	defer poolMutex.Unlock()
-	
+	var removed []uint64
 	end := len(pool) - 1
 	for i := 0; i <= end; {
 		if pool[i].Base.SubId == subID {
 			nodeExist.Remove(pool[i].Base.UniqueKey)
+			removed = append(removed, pool[i].Base.UniqueKey)
 			pool[i] = pool[end]
 			end--
 		} else {
 			i++
 		}
 	}
-	
+
 	pool = pool[:end+1]
+	reindexLocked()
+	poolMutex.Unlock()
+
+	persist(nil, removed)
 }
@@ -0,0 +1,99 @@
+package node
+
+import (
+	"slices"
+	"sync"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+var (
+	offerMutex sync.RWMutex
+	// subOffers 每个订阅最近一次提供的全部节点, 包括因重复未进入节点池的节点
+	subOffers = make(map[uint16]map[uint64]struct{})
+)
+
+// SetOffers 替换订阅提供的节点集合
+func SetOffers(subID uint16, keys map[uint64]struct{}) {
+	offerMutex.Lock()
+	defer offerMutex.Unlock()
+	subOffers[subID] = keys
+}
+
+func DeleteOffers(subID uint16) {
+	offerMutex.Lock()
+	defer offerMutex.Unlock()
+	delete(subOffers, subID)
+}
+
+// ResetOffers 清空全部订阅提供的节点, 各订阅下次更新时重新解析
+func ResetOffers() {
+	offerMutex.Lock()
+	defer offerMutex.Unlock()
+	clear(subOffers)
+}
+
+// HasOffers 订阅是否已记录提供的节点, 重启后需重新解析一次
+func HasOffers(subID uint16) bool {
+	offerMutex.RLock()
+	defer offerMutex.RUnlock()
+	_, ok := subOffers[subID]
+	return ok
+}
+
+// OfferedBy 返回提供该节点的全部订阅
+func OfferedBy(key uint64) []uint16 {
+	offerMutex.RLock()
+	defer offerMutex.RUnlock()
+	var result []uint16
+	for subID, keys := range subOffers {
+		if _, ok := keys[key]; ok {
+			result = append(result, subID)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// GetOverlap 统计订阅之间共有的节点数以及各订阅独有的节点数
+func GetOverlap() nodeModel.Overlap {
+	offerMutex.RLock()
+	defer offerMutex.RUnlock()
+
+	subIDs := make([]uint16, 0, len(subOffers))
+	for subID := range subOffers {
+		subIDs = append(subIDs, subID)
+	}
+	slices.Sort(subIDs)
+	index := make(map[uint16]int, len(subIDs))
+	for i, subID := range subIDs {
+		index[subID] = i
+	}
+
+	offeredBy := make(map[uint64][]int)
+	for subID, keys := range subOffers {
+		for key := range keys {
+			offeredBy[key] = append(offeredBy[key], index[subID])
+		}
+	}
+
+	overlap := nodeModel.Overlap{
+		Subs:   make([]nodeModel.OverlapSub, len(subIDs)),
+		Matrix: make([][]uint32, len(subIDs)),
+	}
+	for i, subID := range subIDs {
+		overlap.Subs[i] = nodeModel.OverlapSub{ID: subID, Total: uint32(len(subOffers[subID]))}
+		overlap.Matrix[i] = make([]uint32, len(subIDs))
+	}
+	for _, subs := range offeredBy {
+		if len(subs) == 1 {
+			overlap.Subs[subs[0]].Unique++
+		}
+		for _, i := range subs {
+			for _, j := range subs {
+				overlap.Matrix[i][j]++
+			}
+		}
+	}
+	return overlap
+}
@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"encoding/gob"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/bestruirui/bestsub/internal/config"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// InitNodePool 从数据库恢复节点池, 存在旧版 session 文件时导入一次
+func InitNodePool(size int) {
+	pool = make([]nodeModel.Data, 0, size)
+	nodeExist = NewExist(size)
+	nodeProcess = NewExist(size)
+
+	loadMarks()
+	loadLabels()
+	importSession()
+
+	nodes, err := op.GetNodeList(context.Background())
+	if err != nil {
+		log.Warnf("restore node pool failed: %v", err)
+		return
+	}
+	var removed []uint64
+	nodes = slices.DeleteFunc(nodes, func(n nodeModel.Data) bool {
+		if IsBlacklisted(n.UniqueKey) {
+			removed = append(removed, n.UniqueKey)
+			return true
+		}
+		return false
+	})
+	rank(nodes)
+	if len(nodes) > size {
+		for _, n := range nodes[size:] {
+			removed = append(removed, n.UniqueKey)
+		}
+		nodes = nodes[:size]
+	}
+	persist(nil, removed)
+	pool = append(pool, nodes...)
+	for _, node := range pool {
+		nodeExist.Add(node.Base.UniqueKey)
+	}
+	labels := resolveLabels(pool)
+	poolMutex.Lock()
+	relabelLocked(labels)
+	poolMutex.Unlock()
+	RefreshInfo()
+	log.Infof("node pool restored, %d nodes", len(pool))
+}
+
+// CloseNodePool 保存节点测试信息
+func CloseNodePool() error {
+	if err := SyncInfo(); err != nil {
+		log.Warnf("save node pool failed: %v", err)
+		return err
+	}
+	log.Debugf("node pool saved")
+	return nil
+}
+
+// SyncInfo 将节点池中全部节点的测试信息批量写入数据库
+func SyncInfo() error {
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	poolMutex.RLock()
+	nodes := snapshotAll()
+	poolMutex.RUnlock()
+	return op.SaveNodes(context.Background(), nodes)
+}
+
+// persistMutex 串行化节点池的数据库写入, 修改节点池与对应的写库在同一临界区内完成
+// 避免 SyncInfo 的快照把刚移出的节点重新写回数据库, 加锁顺序为 persistMutex -> poolMutex
+var persistMutex sync.Mutex
+
+// persist 节点加入或移出节点池后增量写入数据库, 调用方需持有 persistMutex
+func persist(added []nodeModel.Data, removed []uint64) {
+	ctx := context.Background()
+	if err := op.DeleteNodes(ctx, removed); err != nil {
+		log.Warnf("delete nodes failed: %v", err)
+	}
+	if err := op.SaveNodes(ctx, added); err != nil {
+		log.Warnf("save nodes failed: %v", err)
+	}
+}
+
+// importSession 导入旧版 gob 格式的 session 文件, 导入后重命名避免重复导入
+func importSession() {
+	sessionFile := config.Base().Session.NodePath
+	file, err := os.Open(sessionFile)
+	if err != nil {
+		return
+	}
+	var nodes []nodeModel.Data
+	err = gob.NewDecoder(file).Decode(&nodes)
+	file.Close()
+	if err != nil {
+		log.Warnf("import node session failed: %v", err)
+		os.Rename(sessionFile, sessionFile+".bak")
+		return
+	}
+	if err := op.SaveNodes(context.Background(), nodes); err != nil {
+		log.Warnf("import node session failed: %v", err)
+		return
+	}
+	if err := os.Rename(sessionFile, sessionFile+".imported"); err != nil {
+		log.Warnf("rename node session failed: %v", err)
+	}
+	log.Infof("imported %d nodes from %s", len(nodes), sessionFile)
+}
@@ -0,0 +1,214 @@
+package node
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// deadDelay 存活检测失败时记录的延迟
+const deadDelay = 65535
+
+// Policy 节点池准入与淘汰策略, 节点池保留分数最高的节点
+type Policy interface {
+	// Score 返回 [0, 1] 的分数, 越高越优先保留
+	Score(n *nodeModel.Data, stats *PoolStats) float64
+}
+
+// PoolStats 参与排名的全部节点的统计, 用于归一化
+type PoolStats struct {
+	Total        int
+	MaxSpeedDown uint32
+	MaxSpeedUp   uint32
+	Countries    map[string]int
+	DelayBase    float64 // 毫秒, 延迟达到该值时延迟得分为 0
+	Weights      ScoreWeights
+}
+
+var (
+	policyMutex sync.RWMutex
+	policies    = make(map[string]Policy)
+)
+
+// RegisterPolicy 注册节点池策略, 通过 NODE_POOL_POLICY 设置选择
+func RegisterPolicy(name string, p Policy) {
+	policyMutex.Lock()
+	defer policyMutex.Unlock()
+	policies[name] = p
+}
+
+// Policies 返回已注册的策略名称
+func Policies() []string {
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	names := make([]string, 0, len(policies))
+	for name := range policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// currentPolicy 返回 NODE_POOL_POLICY 选择的策略, 设置时已校验名称, 未知时使用 delay
+func currentPolicy() Policy {
+	name := op.GetSettingStr(setting.NODE_POOL_POLICY)
+	policyMutex.RLock()
+	defer policyMutex.RUnlock()
+	if p, ok := policies[name]; ok {
+		return p
+	}
+	return policies["delay"]
+}
+
+func newPoolStats(nodes []nodeModel.Data) *PoolStats {
+	stats := &PoolStats{
+		Total:     len(nodes),
+		Countries: make(map[string]int),
+		DelayBase: float64(op.GetSettingInt(setting.NODE_TEST_TIMEOUT) * 1000),
+	}
+	if stats.DelayBase <= 0 {
+		stats.DelayBase = 5000
+	}
+	if err := json.Unmarshal([]byte(op.GetSettingStr(setting.NODE_SCORE_WEIGHTS)), &stats.Weights); err != nil {
+		log.Warnf("parse node score weights failed: %v", err)
+	}
+	for _, n := range nodes {
+		stats.MaxSpeedDown = max(stats.MaxSpeedDown, n.Info.SpeedDown.Average())
+		stats.MaxSpeedUp = max(stats.MaxSpeedUp, n.Info.SpeedUp.Average())
+		if n.Info.Country != "" {
+			stats.Countries[n.Info.Country]++
+		}
+	}
+	return stats
+}
+
+// rank 按策略计算分数并降序排列, 分数写入 Info.Score, 固定的节点始终排在最前
+func rank(nodes []nodeModel.Data) {
+	policy := currentPolicy()
+	stats := newPoolStats(nodes)
+	scores := make(map[uint64]float64, len(nodes))
+	for i := range nodes {
+		score := policy.Score(&nodes[i], stats)
+		scores[nodes[i].UniqueKey] = score
+		nodes[i].Info.Score = uint8(math.Round(score * 100))
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return scores[nodes[i].UniqueKey] > scores[nodes[j].UniqueKey]
+	})
+	pinnedFirst(nodes)
+}
+
+// Rescore 按当前策略重新计算节点池中的分数
+func Rescore() {
+	poolMutex.Lock()
+	defer poolMutex.Unlock()
+	rank(pool)
+	reindexLocked()
+}
+
+// delayPolicy 仅按平均延迟排名
+type delayPolicy struct{}
+
+func (delayPolicy) Score(n *nodeModel.Data, stats *PoolStats) float64 {
+	return delayScore(n, stats)
+}
+
+// ScoreWeights 综合评分各项的权重
+type ScoreWeights struct {
+	Delay   float64 `json:"delay"`
+	Speed   float64 `json:"speed"`
+	Alive   float64 `json:"alive"`
+	Risk    float64 `json:"risk"`
+	Country float64 `json:"country"`
+	Age     float64 `json:"age"`
+}
+
+// scorePolicy 综合延迟、速度、存活历史、风险、国家多样性与入池时长, 权重由 NODE_SCORE_WEIGHTS 设置
+type scorePolicy struct{}
+
+// maxAge 入池时长达到该值时时长得分为 1
+const maxAge = 7 * 24 * time.Hour
+
+func (scorePolicy) Score(n *nodeModel.Data, stats *PoolStats) float64 {
+	w := stats.Weights
+	total := w.Delay + w.Speed + w.Alive + w.Risk + w.Country + w.Age
+	if total <= 0 {
+		return delayScore(n, stats)
+	}
+	score := w.Delay*delayScore(n, stats) +
+		w.Speed*speedScore(n, stats) +
+		w.Alive*aliveScore(n) +
+		w.Risk*(1-float64(min(n.Info.Risk, 100))/100) +
+		w.Country*countryScore(n, stats) +
+		w.Age*ageScore(n)
+	return score / total
+}
+
+func delayScore(n *nodeModel.Data, stats *PoolStats) float64 {
+	delay := float64(n.Info.Delay.Average())
+	if delay == 0 {
+		return 0
+	}
+	return max(1-delay/stats.DelayBase, 0)
+}
+
+// speedScore 未测速的节点记为 0.5, 避免新节点因尚未测速被淘汰
+func speedScore(n *nodeModel.Data, stats *PoolStats) float64 {
+	down, up := n.Info.SpeedDown.Average(), n.Info.SpeedUp.Average()
+	if down == 0 && up == 0 {
+		return 0.5
+	}
+	var score, count float64
+	if stats.MaxSpeedDown > 0 {
+		score += float64(down) / float64(stats.MaxSpeedDown)
+		count++
+	}
+	if stats.MaxSpeedUp > 0 {
+		score += float64(up) / float64(stats.MaxSpeedUp)
+		count++
+	}
+	return score / count
+}
+
+// aliveScore 最近延迟记录中存活的比例
+func aliveScore(n *nodeModel.Data) float64 {
+	samples := n.Info.Delay.GetAll()
+	if len(samples) == 0 {
+		return 0
+	}
+	alive := 0
+	for _, d := range samples {
+		if d != deadDelay {
+			alive++
+		}
+	}
+	return float64(alive) / float64(len(samples))
+}
+
+// countryScore 所在国家的节点占比越低分数越高, 国家未知记为 0.5
+func countryScore(n *nodeModel.Data, stats *PoolStats) float64 {
+	if n.Info.Country == "" || stats.Total == 0 {
+		return 0.5
+	}
+	return 1 - float64(stats.Countries[n.Info.Country])/float64(stats.Total)
+}
+
+// ageScore 入池时间越久分数越高, 入池时间未知记为 0.5
+func ageScore(n *nodeModel.Data) float64 {
+	if n.Info.AddedAt == 0 {
+		return 0.5
+	}
+	return min(float64(time.Since(time.Unix(n.Info.AddedAt, 0)))/float64(maxAge), 1)
+}
+
+func init() {
+	RegisterPolicy("delay", delayPolicy{})
+	RegisterPolicy("score", scorePolicy{})
+}
@@ -0,0 +1,79 @@
+package node
+
+import (
+	"sort"
+	"strings"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+const defaultPageSize = 50
+
+// Query 按过滤条件、搜索关键字筛选节点池, 排序后分页, 返回符合条件的总数与当前页
+func Query(q nodeModel.Query) (int, []nodeModel.Data) {
+	nodes := *GetByFilter(q.Filter)
+
+	if search := strings.ToLower(strings.TrimSpace(q.Search)); search != "" {
+		matched := nodes[:0]
+		for _, n := range nodes {
+			f := n.Fields()
+			if strings.Contains(strings.ToLower(f.Name), search) || strings.Contains(strings.ToLower(f.Server), search) {
+				matched = append(matched, n)
+			}
+		}
+		nodes = matched
+	}
+
+	if less := sortFunc(q.Sort, nodes); less != nil {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if q.Desc {
+				return less(j, i)
+			}
+			return less(i, j)
+		})
+	}
+
+	total := len(nodes)
+	pageSize := q.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	page := max(q.Page, 1)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+	return total, nodes[start:end]
+}
+
+func sortFunc(field string, nodes []nodeModel.Data) func(i, j int) bool {
+	switch field {
+	case "delay":
+		return func(i, j int) bool { return nodes[i].Info.Delay.Average() < nodes[j].Info.Delay.Average() }
+	case "speed_down", "speed":
+		return func(i, j int) bool { return nodes[i].Info.SpeedDown.Average() < nodes[j].Info.SpeedDown.Average() }
+	case "speed_up":
+		return func(i, j int) bool { return nodes[i].Info.SpeedUp.Average() < nodes[j].Info.SpeedUp.Average() }
+	case "risk":
+		return func(i, j int) bool { return nodes[i].Info.Risk < nodes[j].Info.Risk }
+	case "country":
+		return func(i, j int) bool { return nodes[i].Info.Country < nodes[j].Info.Country }
+	case "score":
+		return func(i, j int) bool { return nodes[i].Info.Score < nodes[j].Info.Score }
+	case "name":
+		names := make(map[uint64]string, len(nodes))
+		for _, n := range nodes {
+			names[n.UniqueKey] = n.Fields().Name
+		}
+		return func(i, j int) bool { return names[nodes[i].UniqueKey] < names[nodes[j].UniqueKey] }
+	}
+	return nil
+}
+
+// GetByKey 根据唯一键获取节点快照
+func GetByKey(key uint64) (nodeModel.Data, bool) {
+	poolMutex.RLock()
+	defer poolMutex.RUnlock()
+	if i := indexLocked(key); i >= 0 {
+		return snapshot(pool[i]), true
+	}
+	return nodeModel.Data{}, false
+}
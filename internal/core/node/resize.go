@@ -0,0 +1,47 @@
+package node
+
+import (
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+// Resize 运行时调整节点池容量, 缩小时按与准入相同的排名淘汰多出的节点
+// 固定节点不会被淘汰, 容量不小于固定节点的数量
+func Resize(size int) nodeModel.Resize {
+	persistMutex.Lock()
+	defer persistMutex.Unlock()
+	size = max(size, PinnedCount())
+	poolMutex.Lock()
+	result := nodeModel.Resize{OldSize: cap(pool), NewSize: size}
+	if size == cap(pool) {
+		result.Count = len(pool)
+		poolMutex.Unlock()
+		return result
+	}
+	var removed []uint64
+	if len(pool) > size {
+		rank(pool)
+		kept := pool[:0]
+		for _, n := range pool {
+			if len(kept) < size || IsPinned(n.UniqueKey) {
+				kept = append(kept, n)
+				continue
+			}
+			nodeExist.Remove(n.UniqueKey)
+			removed = append(removed, n.UniqueKey)
+		}
+		pool = kept
+	}
+	resized := make([]nodeModel.Data, len(pool), size)
+	copy(resized, pool)
+	pool = resized
+	reindexLocked()
+	result.Count = len(pool)
+	result.Evicted = len(removed)
+	poolMutex.Unlock()
+
+	persist(nil, removed)
+	RefreshInfo()
+	log.Infof("node pool resized from %d to %d, %d nodes evicted", result.OldSize, result.NewSize, result.Evicted)
+	return result
+}
@@ -0,0 +1,50 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+func TestResizeKeepsPinned(t *testing.T) {
+	seedPool(5, 5, 2)
+	ctx := context.Background()
+	pins := []uint64{2, 3, 4}
+	for _, key := range pins {
+		if err := Pin(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { Unpin(ctx, key) })
+	}
+
+	if result := Resize(1); result.NewSize != len(pins) || result.Count != len(pins) {
+		t.Fatalf("resize below pinned count: %+v", result)
+	}
+	for _, key := range pins {
+		if _, ok := GetByKey(key); !ok {
+			t.Fatalf("pinned node %d evicted", key)
+		}
+	}
+	checkIndex(t)
+}
+
+func TestPinRejectsFullPool(t *testing.T) {
+	seedPool(3, 3, 2)
+	ctx := context.Background()
+	for _, key := range []uint64{1, 2, 3} {
+		if err := Pin(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { Unpin(ctx, key) })
+	}
+	// 删除节点不会取消固定, 固定数量仍占满容量
+	Delete(3)
+	r := rand.New(rand.NewSource(4))
+	mergeNodesToPool([]nodeModel.Data{testNode(r, 9, 2)})
+	if err := Pin(ctx, 9); !errors.Is(err, ErrPinFull) {
+		t.Fatalf("pin beyond capacity returned %v", err)
+	}
+}
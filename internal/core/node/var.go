@@ -30,4 +30,5 @@ type infoSums struct {
 	sumDelay     uint64
 	sumRisk      uint64
 	count        uint32
+	alive        uint32
 }
@@ -0,0 +1,54 @@
+package subconv
+
+import "gopkg.in/yaml.v3"
+
+func parseClash(raw []byte) ([]map[string]any, []error, error) {
+	var doc struct {
+		Proxies []map[string]any `yaml:"proxies"`
+	}
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, err
+	}
+	var proxies []map[string]any
+	var errs []error
+	for _, p := range doc.Proxies {
+		if err := checkProxy(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, errs, nil
+}
+
+// clash 原版 Clash 仅支持部分协议, Meta 内核可以表达全部节点
+type clash struct {
+	meta bool
+}
+
+func (c *clash) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	if c.meta {
+		return Marshal(proxies), nil
+	}
+	var result []map[string]any
+	var skipped []Unsupported
+	for _, p := range proxies {
+		switch str(p["type"]) {
+		case "ss", "ssr", "vmess", "trojan", "socks5", "http", "snell":
+		default:
+			skipped = append(skipped, unsupported(p, "protocol not supported by clash"))
+			continue
+		}
+		if _, ok := p["reality-opts"]; ok {
+			skipped = append(skipped, unsupported(p, "reality not supported by clash"))
+			continue
+		}
+		result = append(result, p)
+	}
+	return Marshal(result), skipped
+}
+
+func init() {
+	register(&clash{meta: true}, "mihomo", "clashmeta", "meta", "stash")
+	register(&clash{}, "clash")
+}
@@ -0,0 +1,133 @@
+package subconv
+
+import (
+	"strconv"
+	"strings"
+)
+
+type loon struct{}
+
+func (l *loon) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	var buf strings.Builder
+	var skipped []Unsupported
+	buf.WriteString("[Proxy]\n")
+	for _, p := range proxies {
+		line, reason := toLoon(p)
+		if line == "" {
+			skipped = append(skipped, unsupported(p, "%s", reason))
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return []byte(buf.String()), skipped
+}
+
+func toLoon(p map[string]any) (string, string) {
+	fields := []string{"", str(p["server"]), strconv.Itoa(toInt(p["port"]))}
+	tls := false
+	switch t := str(p["type"]); t {
+	case "ss":
+		fields[0] = "Shadowsocks"
+		fields = append(fields, str(p["cipher"]), quote(str(p["password"])))
+		if plugin := str(p["plugin"]); plugin != "" {
+			if plugin != "obfs" {
+				return "", "plugin " + plugin + " not supported by loon"
+			}
+			pluginOpts := opts(p, "plugin-opts")
+			fields = append(fields, "obfs-name="+str(pluginOpts["mode"]), "obfs-host="+str(pluginOpts["host"]))
+		}
+	case "ssr":
+		fields[0] = "ShadowsocksR"
+		fields = append(fields,
+			str(p["cipher"]),
+			quote(str(p["password"])),
+			"protocol="+str(p["protocol"]),
+			"protocol-param="+str(p["protocol-param"]),
+			"obfs="+str(p["obfs"]),
+			"obfs-param="+str(p["obfs-param"]),
+		)
+	case "vmess":
+		fields[0] = "vmess"
+		fields = append(fields, str(p["cipher"]), quote(str(p["uuid"])))
+		if aid := toInt(p["alterId"]); aid != 0 {
+			fields = append(fields, "alterId="+strconv.Itoa(aid))
+		}
+		tls = toBool(p["tls"])
+	case "vless":
+		fields[0] = "VLESS"
+		fields = append(fields, quote(str(p["uuid"])))
+		if flow := str(p["flow"]); flow != "" {
+			fields = append(fields, "flow="+flow)
+		}
+		if reality, ok := p["reality-opts"].(map[string]any); ok {
+			fields = append(fields, "public-key="+str(reality["public-key"]), "short-id="+str(reality["short-id"]))
+		}
+		tls = toBool(p["tls"])
+	case "trojan":
+		fields[0] = "trojan"
+		fields = append(fields, quote(str(p["password"])))
+	case "hysteria2":
+		fields[0] = "Hysteria2"
+		fields = append(fields, quote(str(p["password"])))
+		if obfs := str(p["obfs"]); obfs != "" {
+			if obfs != "salamander" {
+				return "", "obfs " + obfs + " not supported by loon"
+			}
+			fields = append(fields, "salamander-password="+str(p["obfs-password"]))
+		}
+	case "socks5", "http":
+		fields[0] = t
+		if t == "http" && toBool(p["tls"]) {
+			fields[0] = "https"
+		}
+		if user := str(p["username"]); user != "" {
+			fields = append(fields, user, quote(str(p["password"])))
+		}
+	default:
+		return "", "protocol not supported by loon"
+	}
+	switch network(p) {
+	case "tcp":
+		if fields[0] == "vmess" || fields[0] == "VLESS" || fields[0] == "trojan" {
+			fields = append(fields, "transport=tcp")
+		}
+	case "ws":
+		if fields[0] != "vmess" && fields[0] != "VLESS" && fields[0] != "trojan" {
+			return "", "ws not supported by loon for " + fields[0]
+		}
+		fields = append(fields, "transport=ws", "path="+str(opts(p, "ws-opts")["path"]))
+		if host := wsHost(p); host != "" {
+			fields = append(fields, "host="+host)
+		}
+	case "http":
+		if fields[0] != "vmess" {
+			return "", "http not supported by loon for " + fields[0]
+		}
+		httpOpts := opts(p, "http-opts")
+		fields = append(fields, "transport=http", "path="+first(httpOpts["path"]), "host="+first(opts(httpOpts, "headers")["Host"]))
+	default:
+		return "", "network " + network(p) + " not supported by loon"
+	}
+	if tls {
+		fields = append(fields, "over-tls=true")
+	}
+	if s := sni(p); s != "" {
+		fields = append(fields, "sni="+s)
+	}
+	if toBool(p["skip-cert-verify"]) {
+		fields = append(fields, "skip-cert-verify=true")
+	}
+	if t := str(p["type"]); t != "http" && t != "socks5" && toBool(p["udp"]) {
+		fields = append(fields, "udp=true")
+	}
+	return confNameReplacer.Replace(str(p["name"])) + " = " + strings.Join(fields, ","), ""
+}
+
+func quote(s string) string {
+	return `"` + s + `"`
+}
+
+func init() {
+	register(&loon{}, "loon")
+}
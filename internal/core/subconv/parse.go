@@ -0,0 +1,197 @@
+package subconv
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	mihomoHeader = []byte("proxies:\n")
+	mihomoDash   = []byte("  - ")
+	mihomoLine   = []byte("\n")
+)
+
+var errUnknownFormat = errors.New("unknown subscription format")
+
+// Parse 将订阅原始内容解析为 mihomo 节点列表
+// 支持 base64/明文 URI 列表、Clash/mihomo YAML 以及 sing-box JSON
+// 返回成功解析的节点以及每条解析失败的错误
+func Parse(raw []byte) ([]map[string]any, []error) {
+	raw = bytes.TrimSpace(bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")))
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	if raw[0] == '{' {
+		if proxies, errs, err := parseSingBox(raw); err == nil {
+			return proxies, errs
+		}
+	}
+	if bytes.Contains(raw, []byte("proxies:")) {
+		if proxies, errs, err := parseClash(raw); err == nil {
+			return proxies, errs
+		}
+	}
+	if !bytes.Contains(raw, []byte("://")) {
+		decoded, err := decodeBase64(string(raw))
+		if err != nil || !bytes.Contains(decoded, []byte("://")) {
+			return nil, []error{errUnknownFormat}
+		}
+		raw = decoded
+	}
+	return parseURIList(raw)
+}
+
+// Marshal 将节点列表编码为 fetch 使用的 mihomo 格式, 每行一个节点
+func Marshal(proxies []map[string]any) []byte {
+	var buf bytes.Buffer
+	buf.Write(mihomoHeader)
+	for _, p := range proxies {
+		line, err := json.Marshal(p)
+		if err != nil {
+			continue
+		}
+		buf.Write(mihomoDash)
+		buf.Write(line)
+		buf.Write(mihomoLine)
+	}
+	return buf.Bytes()
+}
+
+// ToMihomo 优先使用内置解析器, 无法识别时回退到 Sub-Store
+func ToMihomo(raw []byte) ([]byte, []error) {
+	proxies, errs := Parse(raw)
+	if len(proxies) == 0 && len(errs) > 0 && errors.Is(errs[0], errUnknownFormat) {
+		if remote := ConvertData(string(raw), "mihomo"); remote != "" {
+			return []byte(remote), nil
+		}
+	}
+	return Marshal(proxies), errs
+}
+
+func parseURIList(raw []byte) ([]map[string]any, []error) {
+	var proxies []map[string]any
+	var errs []error
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		p, err := parseURI(line)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", redact(line), err))
+			continue
+		}
+		if err := checkProxy(p); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, errs
+}
+
+func checkProxy(p map[string]any) error {
+	if p == nil {
+		return errors.New("empty proxy")
+	}
+	if str(p["type"]) == "" {
+		return errors.New("missing field: type")
+	}
+	if str(p["server"]) == "" {
+		return errors.New("missing field: server")
+	}
+	if toInt(p["port"]) == 0 {
+		return errors.New("missing field: port")
+	}
+	if str(p["name"]) == "" {
+		p["name"] = fmt.Sprintf("%s:%d", str(p["server"]), toInt(p["port"]))
+	}
+	return nil
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\r', '\n', ' ', '\t':
+			return -1
+		}
+		return r
+	}, s)
+	var err error
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+		base64.URLEncoding,
+		base64.RawURLEncoding,
+	} {
+		var b []byte
+		if b, err = enc.DecodeString(s); err == nil {
+			return b, nil
+		}
+	}
+	return nil, err
+}
+
+func str(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func toInt(v any) int {
+	switch t := v.(type) {
+	case int:
+		return t
+	case int64:
+		return int(t)
+	case uint16:
+		return int(t)
+	case float64:
+		return int(t)
+	case string:
+		i, _ := strconv.Atoi(strings.TrimSpace(t))
+		return i
+	default:
+		return 0
+	}
+}
+
+func toBool(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t == "1" || strings.EqualFold(t, "true")
+	case float64:
+		return t != 0
+	case int:
+		return t != 0
+	default:
+		return false
+	}
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var result []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
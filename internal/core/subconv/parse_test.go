@@ -0,0 +1,213 @@
+package subconv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func b64(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// checkFields 比较 want 中列出的字段, 按 JSON 编码比较以忽略数字与切片的具体类型
+func checkFields(t *testing.T, name string, got, want map[string]any) {
+	t.Helper()
+	for key, value := range want {
+		g, _ := json.Marshal(got[key])
+		w, _ := json.Marshal(value)
+		if string(g) != string(w) {
+			t.Errorf("%s: %s = %s, want %s", name, key, g, w)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want []map[string]any
+	}{
+		{
+			name: "vmess json",
+			raw: "vmess://" + b64(`{"v":"2","ps":"vm","add":"1.1.1.1","port":"443","id":"b831381d-6324-4d53-ad4f-8cda48b30811",`+
+				`"aid":"0","scy":"auto","net":"ws","path":"/ws","host":"cdn.example.com","tls":"tls","sni":"sni.example.com"}`),
+			want: []map[string]any{{
+				"name": "vm", "type": "vmess", "server": "1.1.1.1", "port": 443,
+				"uuid": "b831381d-6324-4d53-ad4f-8cda48b30811", "alterId": 0, "cipher": "auto",
+				"tls": true, "servername": "sni.example.com", "network": "ws",
+				"ws-opts": map[string]any{"path": "/ws", "headers": map[string]any{"Host": "cdn.example.com"}},
+			}},
+		},
+		{
+			name: "vmess http header",
+			raw:  "vmess://" + b64(`{"ps":"vh","add":"1.1.1.1","port":80,"id":"u","aid":2,"net":"tcp","type":"http","path":"/a","host":"h.example.com"}`),
+			want: []map[string]any{{
+				"type": "vmess", "port": 80, "alterId": 2, "cipher": "auto", "network": "http",
+				"http-opts": map[string]any{"path": []string{"/a"}, "headers": map[string]any{"Host": []string{"h.example.com"}}},
+			}},
+		},
+		{
+			name: "ss sip002",
+			raw:  "ss://" + base64.RawURLEncoding.EncodeToString([]byte("aes-256-gcm:pass")) + "@1.1.1.1:8388#SIP%20002",
+			want: []map[string]any{{
+				"name": "SIP 002", "type": "ss", "server": "1.1.1.1", "port": 8388,
+				"cipher": "aes-256-gcm", "password": "pass",
+			}},
+		},
+		{
+			name: "ss sip002 plain userinfo",
+			raw:  "ss://2022-blake3-aes-128-gcm:a2V5@[2001:db8::1]:443#v6",
+			want: []map[string]any{{
+				"type": "ss", "server": "2001:db8::1", "port": 443,
+				"cipher": "2022-blake3-aes-128-gcm", "password": "a2V5",
+			}},
+		},
+		{
+			name: "ss legacy",
+			raw:  "ss://" + b64("chacha20-ietf-poly1305:p@ss@example.com:8388") + "#legacy",
+			want: []map[string]any{{
+				"name": "legacy", "type": "ss", "server": "example.com", "port": 8388,
+				"cipher": "chacha20-ietf-poly1305", "password": "p@ss",
+			}},
+		},
+		{
+			name: "ss obfs plugin",
+			raw:  "ss://" + b64("aes-128-gcm:pass") + "@1.1.1.1:8388/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dbing.com#obfs",
+			want: []map[string]any{{
+				"type": "ss", "cipher": "aes-128-gcm", "password": "pass", "plugin": "obfs",
+				"plugin-opts": map[string]any{"mode": "http", "host": "bing.com"},
+			}},
+		},
+		{
+			name: "ss v2ray plugin",
+			raw:  "ss://" + b64("aes-128-gcm:pass") + "@1.1.1.1:443/?plugin=v2ray-plugin%3Bmode%3Dwebsocket%3Btls%3Bhost%3Dcdn.example.com%3Bpath%3D%2Fss#v2",
+			want: []map[string]any{{
+				"type": "ss", "plugin": "v2ray-plugin",
+				"plugin-opts": map[string]any{"mode": "websocket", "tls": true, "host": "cdn.example.com", "path": "/ss"},
+			}},
+		},
+		{
+			name: "trojan grpc",
+			raw:  "trojan://pass@example.com:443?security=tls&type=grpc&serviceName=svc&sni=sni.example.com&allowInsecure=1#tg",
+			want: []map[string]any{{
+				"name": "tg", "type": "trojan", "server": "example.com", "port": 443, "password": "pass",
+				"sni": "sni.example.com", "skip-cert-verify": true, "network": "grpc",
+				"grpc-opts": map[string]any{"grpc-service-name": "svc"},
+			}},
+		},
+		{
+			name: "trojan ws",
+			raw:  "trojan://pass@example.com:443?type=ws&path=%2Fws%3Fed%3D2048&host=cdn.example.com#tw",
+			want: []map[string]any{{
+				"type": "trojan", "network": "ws",
+				"ws-opts": map[string]any{"path": "/ws?ed=2048", "headers": map[string]any{"Host": "cdn.example.com"}},
+			}},
+		},
+		{
+			name: "vless reality",
+			raw: "vless://b831381d-6324-4d53-ad4f-8cda48b30811@1.1.1.1:443?security=reality&pbk=pubkey&sid=6ba85179" +
+				"&sni=www.example.com&fp=chrome&flow=xtls-rprx-vision&type=tcp#vr",
+			want: []map[string]any{{
+				"name": "vr", "type": "vless", "server": "1.1.1.1", "port": 443,
+				"uuid": "b831381d-6324-4d53-ad4f-8cda48b30811", "flow": "xtls-rprx-vision",
+				"tls": true, "servername": "www.example.com", "client-fingerprint": "chrome",
+				"reality-opts": map[string]any{"public-key": "pubkey", "short-id": "6ba85179"},
+			}},
+		},
+		{
+			name: "hysteria2 obfs",
+			raw:  "hysteria2://pass@example.com:443?obfs=salamander&obfs-password=secret&sni=sni.example.com&insecure=1&mport=20000-30000#hy",
+			want: []map[string]any{{
+				"name": "hy", "type": "hysteria2", "server": "example.com", "port": 443, "password": "pass",
+				"obfs": "salamander", "obfs-password": "secret", "sni": "sni.example.com",
+				"skip-cert-verify": true, "ports": "20000-30000",
+			}},
+		},
+		{
+			name: "base64 list",
+			raw: b64("# comment\r\ntrojan://pass@example.com:443#a\r\n\r\n" +
+				"ss://" + b64("aes-128-gcm:pass") + "@1.1.1.1:8388#b\r\n"),
+			want: []map[string]any{
+				{"name": "a", "type": "trojan"},
+				{"name": "b", "type": "ss"},
+			},
+		},
+		{
+			name: "base64 list wrapped",
+			raw:  base64.RawURLEncoding.EncodeToString([]byte("trojan://pass@example.com:443#a\nhy2://pass@example.com:443")),
+			want: []map[string]any{
+				{"name": "a", "type": "trojan"},
+				{"name": "example.com:443", "type": "hysteria2"},
+			},
+		},
+		{
+			name: "clash flow style",
+			raw: "port: 7890\nproxies:\n" +
+				"  - {name: a, type: ss, server: 1.1.1.1, port: 8388, cipher: aes-128-gcm, password: pass}\n" +
+				"  - {name: b, type: vmess, server: example.com, port: 443, uuid: u, alterId: 0, cipher: auto, tls: true, " +
+				"network: ws, ws-opts: {path: /ws, headers: {Host: cdn.example.com}}}\n" +
+				"  - {name: c, type: ss, server: 1.1.1.1}\n" +
+				"proxy-groups: []\n",
+			want: []map[string]any{
+				{"name": "a", "type": "ss", "server": "1.1.1.1", "port": 8388, "cipher": "aes-128-gcm", "password": "pass"},
+				{"name": "b", "type": "vmess", "tls": true, "network": "ws",
+					"ws-opts": map[string]any{"path": "/ws", "headers": map[string]any{"Host": "cdn.example.com"}}},
+			},
+		},
+		{
+			name: "sing-box outbounds",
+			raw: `{"outbounds":[
+				{"type":"selector","tag":"proxy","outbounds":["vr","h2"]},
+				{"type":"vless","tag":"vr","server":"1.1.1.1","server_port":443,"uuid":"u","flow":"xtls-rprx-vision",
+					"tls":{"enabled":true,"server_name":"www.example.com","utls":{"enabled":true,"fingerprint":"chrome"},
+					"reality":{"enabled":true,"public_key":"pubkey","short_id":"6ba85179"}}},
+				{"type":"hysteria2","tag":"h2","server":"example.com","server_port":443,"password":"pass",
+					"obfs":{"type":"salamander","password":"secret"},"tls":{"enabled":true,"server_name":"sni.example.com"}},
+				{"type":"vmess","tag":"vg","server":"example.com","server_port":443,"uuid":"u",
+					"transport":{"type":"grpc","service_name":"svc"}},
+				{"type":"direct","tag":"direct"}
+			]}`,
+			want: []map[string]any{
+				{"name": "vr", "type": "vless", "port": 443, "flow": "xtls-rprx-vision", "tls": true,
+					"servername": "www.example.com", "client-fingerprint": "chrome",
+					"reality-opts": map[string]any{"public-key": "pubkey", "short-id": "6ba85179"}},
+				{"name": "h2", "type": "hysteria2", "obfs": "salamander", "obfs-password": "secret", "sni": "sni.example.com"},
+				{"name": "vg", "type": "vmess", "cipher": "auto", "network": "grpc",
+					"grpc-opts": map[string]any{"grpc-service-name": "svc"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxies, _ := Parse([]byte(tt.raw))
+			if len(proxies) != len(tt.want) {
+				t.Fatalf("got %d proxies, want %d: %v", len(proxies), len(tt.want), proxies)
+			}
+			for i, want := range tt.want {
+				checkFields(t, str(want["name"]), proxies[i], want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		proxies int
+		errs    int
+	}{
+		{"empty", " \n", 0, 0},
+		{"unknown format", "hello world", 0, 1},
+		{"unsupported protocol", "wireguard://key@1.1.1.1:51820\ntrojan://pass@example.com:443", 1, 1},
+		{"missing port", "trojan://pass@example.com", 0, 1},
+		{"clash missing server", "proxies:\n  - {name: a, type: ss, port: 1}\n", 0, 1},
+	}
+	for _, tt := range tests {
+		proxies, errs := Parse([]byte(tt.raw))
+		if len(proxies) != tt.proxies || len(errs) != tt.errs {
+			t.Errorf("%s: got %d proxies, %d errors %v, want %d, %d", tt.name, len(proxies), len(errs), errs, tt.proxies, tt.errs)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package subconv
+
+import (
+	"strconv"
+	"strings"
+)
+
+// quanX Quantumult X 的 server_local 格式
+type quanX struct{}
+
+func (q *quanX) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	var buf strings.Builder
+	var skipped []Unsupported
+	for _, p := range proxies {
+		line, reason := toQuanX(p)
+		if line == "" {
+			skipped = append(skipped, unsupported(p, "%s", reason))
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return []byte(buf.String()), skipped
+}
+
+func toQuanX(p map[string]any) (string, string) {
+	var fields []string
+	addr := str(p["server"]) + ":" + strconv.Itoa(toInt(p["port"]))
+	tls := toBool(p["tls"])
+	t := str(p["type"])
+	switch t {
+	case "ss":
+		fields = append(fields,
+			"shadowsocks="+addr,
+			"method="+str(p["cipher"]),
+			"password="+str(p["password"]),
+		)
+		if plugin := str(p["plugin"]); plugin != "" {
+			pluginOpts := opts(p, "plugin-opts")
+			switch plugin {
+			case "obfs":
+				fields = append(fields, "obfs="+str(pluginOpts["mode"]), "obfs-host="+str(pluginOpts["host"]))
+			case "v2ray-plugin":
+				obfs := "ws"
+				if toBool(pluginOpts["tls"]) {
+					obfs = "wss"
+				}
+				fields = append(fields, "obfs="+obfs, "obfs-host="+str(pluginOpts["host"]), "obfs-uri="+str(pluginOpts["path"]))
+			default:
+				return "", "plugin " + plugin + " not supported by quantumult x"
+			}
+		}
+	case "ssr":
+		fields = append(fields,
+			"shadowsocks="+addr,
+			"method="+str(p["cipher"]),
+			"password="+str(p["password"]),
+			"ssr-protocol="+str(p["protocol"]),
+			"ssr-protocol-param="+str(p["protocol-param"]),
+			"obfs="+str(p["obfs"]),
+			"obfs-host="+str(p["obfs-param"]),
+		)
+	case "vmess":
+		cipher := str(p["cipher"])
+		if cipher == "auto" || cipher == "" {
+			cipher = "chacha20-poly1305"
+		}
+		fields = append(fields, "vmess="+addr, "method="+cipher, "password="+str(p["uuid"]))
+		if toInt(p["alterId"]) != 0 {
+			fields = append(fields, "aead=false")
+		}
+	case "vless":
+		if _, ok := p["reality-opts"]; ok {
+			return "", "reality not supported by quantumult x"
+		}
+		if str(p["flow"]) != "" {
+			return "", "flow not supported by quantumult x"
+		}
+		fields = append(fields, "vless="+addr, "method=none", "password="+str(p["uuid"]))
+	case "trojan":
+		fields = append(fields, "trojan="+addr, "password="+str(p["password"]))
+		tls = true
+	case "http":
+		fields = append(fields, "http="+addr)
+	case "socks5":
+		fields = append(fields, "socks5="+addr)
+	default:
+		return "", "protocol not supported by quantumult x"
+	}
+	if t == "http" || t == "socks5" {
+		if user := str(p["username"]); user != "" {
+			fields = append(fields, "username="+user, "password="+str(p["password"]))
+		}
+	}
+	switch network(p) {
+	case "tcp":
+		if tls {
+			if t == "trojan" || t == "http" || t == "socks5" {
+				fields = append(fields, "over-tls=true")
+			} else {
+				fields = append(fields, "obfs=over-tls")
+			}
+		}
+	case "ws":
+		obfs := "ws"
+		if tls {
+			obfs = "wss"
+		}
+		fields = append(fields, "obfs="+obfs, "obfs-uri="+str(opts(p, "ws-opts")["path"]))
+		if host := wsHost(p); host != "" {
+			fields = append(fields, "obfs-host="+host)
+		}
+	case "http":
+		httpOpts := opts(p, "http-opts")
+		fields = append(fields, "obfs=http", "obfs-uri="+first(httpOpts["path"]), "obfs-host="+first(opts(httpOpts, "headers")["Host"]))
+	default:
+		return "", "network " + network(p) + " not supported by quantumult x"
+	}
+	if tls {
+		if s := sni(p); s != "" {
+			fields = append(fields, "tls-host="+s)
+		}
+		if toBool(p["skip-cert-verify"]) {
+			fields = append(fields, "tls-verification=false")
+		}
+	}
+	if toBool(p["udp"]) {
+		fields = append(fields, "udp-relay=true")
+	}
+	fields = append(fields, "tag="+confNameReplacer.Replace(str(p["name"])))
+	return strings.Join(fields, ", "), ""
+}
+
+func init() {
+	register(&quanX{}, "quantumultx", "quanx", "qx")
+}
@@ -0,0 +1,42 @@
+package subconv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseErrorsRedacted(t *testing.T) {
+	const secret = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	lines := []string{
+		"vless://" + secret + "@example.com:abc?security=tls",
+		"trojan://" + secret + "@example.com:%zz",
+		"hysteria2://" + secret + "@[::1",
+		"not a link " + secret,
+		"vmess://" + secret,
+	}
+	for _, line := range lines {
+		_, errs := parseURIList([]byte(line))
+		if len(errs) == 0 {
+			t.Errorf("%q parsed without error", line)
+			continue
+		}
+		for _, err := range errs {
+			if strings.Contains(err.Error(), secret[:8]) {
+				t.Errorf("error %q leaks the link", err)
+			}
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := map[string]string{
+		"ss://YWVzLTI1Ni1nY206cGFzcw@1.1.1.1:443": "ss://YWVz***",
+		"vless://ab":      "vless://ab",
+		"plain text line": "plai***",
+	}
+	for in, want := range tests {
+		if got := redact(in); got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package subconv
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+func parseSingBox(raw []byte) ([]map[string]any, []error, error) {
+	var doc struct {
+		Outbounds []map[string]any `json:"outbounds"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, nil, err
+	}
+	if doc.Outbounds == nil {
+		return nil, nil, errUnknownFormat
+	}
+	var proxies []map[string]any
+	var errs []error
+	for _, o := range doc.Outbounds {
+		switch str(o["type"]) {
+		case "selector", "urltest", "direct", "block", "dns":
+			continue
+		}
+		p, err := fromSingBox(o)
+		if err == nil {
+			err = checkProxy(p)
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies, errs, nil
+}
+
+func fromSingBox(o map[string]any) (map[string]any, error) {
+	p := map[string]any{
+		"name":   str(o["tag"]),
+		"server": str(o["server"]),
+		"port":   toInt(o["server_port"]),
+	}
+	switch t := str(o["type"]); t {
+	case "shadowsocks":
+		p["type"] = "ss"
+		p["cipher"] = str(o["method"])
+		p["password"] = str(o["password"])
+		if plugin := str(o["plugin"]); plugin != "" {
+			setSSPlugin(p, plugin+";"+str(o["plugin_opts"]))
+		}
+	case "vmess":
+		p["type"] = "vmess"
+		p["uuid"] = str(o["uuid"])
+		p["alterId"] = toInt(o["alter_id"])
+		cipher := str(o["security"])
+		if cipher == "" {
+			cipher = "auto"
+		}
+		p["cipher"] = cipher
+	case "vless":
+		p["type"] = "vless"
+		p["uuid"] = str(o["uuid"])
+		if flow := str(o["flow"]); flow != "" {
+			p["flow"] = flow
+		}
+	case "trojan":
+		p["type"] = "trojan"
+		p["password"] = str(o["password"])
+	case "hysteria":
+		p["type"] = "hysteria"
+		p["auth-str"] = str(o["auth_str"])
+		if up := str(o["up_mbps"]); up != "" {
+			p["up"] = up
+		}
+		if down := str(o["down_mbps"]); down != "" {
+			p["down"] = down
+		}
+		if obfs := str(o["obfs"]); obfs != "" {
+			p["obfs"] = obfs
+		}
+	case "hysteria2":
+		p["type"] = "hysteria2"
+		p["password"] = str(o["password"])
+		if obfs, ok := o["obfs"].(map[string]any); ok {
+			p["obfs"] = str(obfs["type"])
+			p["obfs-password"] = str(obfs["password"])
+		}
+	case "tuic":
+		p["type"] = "tuic"
+		p["uuid"] = str(o["uuid"])
+		p["password"] = str(o["password"])
+		if cc := str(o["congestion_control"]); cc != "" {
+			p["congestion-controller"] = cc
+		}
+		if mode := str(o["udp_relay_mode"]); mode != "" {
+			p["udp-relay-mode"] = mode
+		}
+	case "socks":
+		p["type"] = "socks5"
+		p["username"] = str(o["username"])
+		p["password"] = str(o["password"])
+	case "http":
+		p["type"] = "http"
+		p["username"] = str(o["username"])
+		p["password"] = str(o["password"])
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", t)
+	}
+	// sing-box 出站默认同时支持 tcp 与 udp, network 为 tcp 时仅 tcp
+	if p["type"] != "http" && str(o["network"]) != "tcp" {
+		p["udp"] = true
+	}
+	if tls, ok := o["tls"].(map[string]any); ok && toBool(tls["enabled"]) {
+		setSingBoxTLS(p, tls)
+	}
+	if transport, ok := o["transport"].(map[string]any); ok {
+		setSingBoxTransport(p, transport)
+	}
+	return p, nil
+}
+
+func setSingBoxTLS(p map[string]any, tls map[string]any) {
+	switch p["type"] {
+	case "vmess", "vless", "http", "socks5":
+		p["tls"] = true
+	}
+	if sni := str(tls["server_name"]); sni != "" {
+		switch p["type"] {
+		case "vmess", "vless":
+			p["servername"] = sni
+		default:
+			p["sni"] = sni
+		}
+	}
+	if toBool(tls["insecure"]) {
+		p["skip-cert-verify"] = true
+	}
+	if alpn, ok := tls["alpn"].([]any); ok && len(alpn) > 0 {
+		p["alpn"] = alpn
+	}
+	if utls, ok := tls["utls"].(map[string]any); ok && toBool(utls["enabled"]) {
+		p["client-fingerprint"] = str(utls["fingerprint"])
+	}
+	if reality, ok := tls["reality"].(map[string]any); ok && toBool(reality["enabled"]) {
+		opts := map[string]any{"public-key": str(reality["public_key"])}
+		if sid := str(reality["short_id"]); sid != "" {
+			opts["short-id"] = sid
+		}
+		p["reality-opts"] = opts
+	}
+}
+
+func setSingBoxTransport(p map[string]any, transport map[string]any) {
+	var host string
+	switch h := transport["host"].(type) {
+	case string:
+		host = h
+	case []any:
+		if len(h) > 0 {
+			host = str(h[0])
+		}
+	}
+	if headers, ok := transport["headers"].(map[string]any); ok && host == "" {
+		host = str(headers["Host"])
+	}
+	network := str(transport["type"])
+	if network == "http" {
+		network = "h2"
+	}
+	setTransport(p, network, str(transport["path"]), host, str(transport["service_name"]))
+}
+
+type singBox struct{}
+
+func (s *singBox) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	outbounds := make([]map[string]any, 0, len(proxies))
+	var skipped []Unsupported
+	for _, p := range proxies {
+		o, reason := toSingBox(p)
+		if o == nil {
+			skipped = append(skipped, unsupported(p, "%s", reason))
+			continue
+		}
+		outbounds = append(outbounds, o)
+	}
+	out, err := json.MarshalIndent(map[string]any{"outbounds": outbounds}, "", "  ")
+	if err != nil {
+		return nil, skipped
+	}
+	return out, skipped
+}
+
+func toSingBox(p map[string]any) (map[string]any, string) {
+	o := map[string]any{
+		"tag":         str(p["name"]),
+		"server":      str(p["server"]),
+		"server_port": toInt(p["port"]),
+	}
+	tls := false
+	switch t := str(p["type"]); t {
+	case "ss":
+		o["type"] = "shadowsocks"
+		o["method"] = str(p["cipher"])
+		o["password"] = str(p["password"])
+		if plugin := str(p["plugin"]); plugin != "" {
+			pluginOpts := opts(p, "plugin-opts")
+			switch plugin {
+			case "obfs":
+				o["plugin"] = "obfs-local"
+				o["plugin_opts"] = fmt.Sprintf("obfs=%s;obfs-host=%s", str(pluginOpts["mode"]), str(pluginOpts["host"]))
+			case "v2ray-plugin":
+				pluginStr := fmt.Sprintf("mode=websocket;host=%s;path=%s", str(pluginOpts["host"]), str(pluginOpts["path"]))
+				if toBool(pluginOpts["tls"]) {
+					pluginStr += ";tls"
+				}
+				o["plugin"] = "v2ray-plugin"
+				o["plugin_opts"] = pluginStr
+			default:
+				return nil, "plugin " + plugin + " not supported by sing-box"
+			}
+		}
+	case "vmess":
+		o["type"] = "vmess"
+		o["uuid"] = str(p["uuid"])
+		o["alter_id"] = toInt(p["alterId"])
+		o["security"] = str(p["cipher"])
+		tls = toBool(p["tls"])
+	case "vless":
+		o["type"] = "vless"
+		o["uuid"] = str(p["uuid"])
+		if flow := str(p["flow"]); flow != "" {
+			o["flow"] = flow
+		}
+		tls = toBool(p["tls"])
+	case "trojan":
+		o["type"] = "trojan"
+		o["password"] = str(p["password"])
+		tls = true
+	case "hysteria":
+		o["type"] = "hysteria"
+		o["auth_str"] = str(p["auth-str"])
+		o["up_mbps"] = toInt(strings.TrimSuffix(str(p["up"]), " Mbps"))
+		o["down_mbps"] = toInt(strings.TrimSuffix(str(p["down"]), " Mbps"))
+		if obfs := str(p["obfs"]); obfs != "" {
+			o["obfs"] = obfs
+		}
+		tls = true
+	case "hysteria2":
+		o["type"] = "hysteria2"
+		o["password"] = str(p["password"])
+		if obfs := str(p["obfs"]); obfs != "" {
+			o["obfs"] = map[string]any{"type": obfs, "password": str(p["obfs-password"])}
+		}
+		tls = true
+	case "tuic":
+		o["type"] = "tuic"
+		o["uuid"] = str(p["uuid"])
+		o["password"] = str(p["password"])
+		if cc := str(p["congestion-controller"]); cc != "" {
+			o["congestion_control"] = cc
+		}
+		if mode := str(p["udp-relay-mode"]); mode != "" {
+			o["udp_relay_mode"] = mode
+		}
+		tls = true
+	case "socks5":
+		o["type"] = "socks"
+		o["username"] = str(p["username"])
+		o["password"] = str(p["password"])
+	case "http":
+		o["type"] = "http"
+		o["username"] = str(p["username"])
+		o["password"] = str(p["password"])
+		tls = toBool(p["tls"])
+	default:
+		return nil, "protocol not supported by sing-box"
+	}
+	if tls {
+		tlsOpts := map[string]any{"enabled": true}
+		if s := sni(p); s != "" {
+			tlsOpts["server_name"] = s
+		}
+		if toBool(p["skip-cert-verify"]) {
+			tlsOpts["insecure"] = true
+		}
+		if alpn := list(p["alpn"]); len(alpn) > 0 {
+			tlsOpts["alpn"] = alpn
+		}
+		if fp := str(p["client-fingerprint"]); fp != "" {
+			tlsOpts["utls"] = map[string]any{"enabled": true, "fingerprint": fp}
+		}
+		if reality, ok := p["reality-opts"].(map[string]any); ok {
+			tlsOpts["reality"] = map[string]any{
+				"enabled":    true,
+				"public_key": str(reality["public-key"]),
+				"short_id":   str(reality["short-id"]),
+			}
+		}
+		o["tls"] = tlsOpts
+	}
+	switch network(p) {
+	case "tcp":
+	case "ws":
+		ws := opts(p, "ws-opts")
+		transport := map[string]any{"type": "ws", "path": str(ws["path"])}
+		if host := wsHost(p); host != "" {
+			transport["headers"] = map[string]any{"Host": host}
+		}
+		if toBool(ws["v2ray-http-upgrade"]) {
+			transport["type"] = "httpupgrade"
+			delete(transport, "headers")
+			transport["host"] = wsHost(p)
+		}
+		o["transport"] = transport
+	case "grpc":
+		o["transport"] = map[string]any{
+			"type":         "grpc",
+			"service_name": str(opts(p, "grpc-opts")["grpc-service-name"]),
+		}
+	case "h2":
+		h2 := opts(p, "h2-opts")
+		o["transport"] = map[string]any{
+			"type": "http",
+			"host": list(h2["host"]),
+			"path": str(h2["path"]),
+		}
+	default:
+		return nil, "network " + network(p) + " not supported by sing-box"
+	}
+	return o, ""
+}
+
+func init() {
+	register(&singBox{}, "sing-box", "singbox")
+}
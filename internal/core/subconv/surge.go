@@ -0,0 +1,127 @@
+package subconv
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var confNameReplacer = strings.NewReplacer(",", " ", "=", " ", "\n", " ")
+
+type surge struct{}
+
+func (s *surge) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	var buf strings.Builder
+	var skipped []Unsupported
+	buf.WriteString("[Proxy]\n")
+	for _, p := range proxies {
+		line, reason := toSurge(p)
+		if line == "" {
+			skipped = append(skipped, unsupported(p, "%s", reason))
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+	}
+	return []byte(buf.String()), skipped
+}
+
+func toSurge(p map[string]any) (string, string) {
+	fields := []string{"", str(p["server"]), strconv.Itoa(toInt(p["port"]))}
+	tls := false
+	switch t := str(p["type"]); t {
+	case "ss":
+		fields[0] = "ss"
+		fields = append(fields,
+			"encrypt-method="+str(p["cipher"]),
+			"password="+str(p["password"]),
+		)
+		if plugin := str(p["plugin"]); plugin != "" {
+			if plugin != "obfs" {
+				return "", "plugin " + plugin + " not supported by surge"
+			}
+			pluginOpts := opts(p, "plugin-opts")
+			fields = append(fields, "obfs="+str(pluginOpts["mode"]), "obfs-host="+str(pluginOpts["host"]))
+		}
+	case "vmess":
+		fields[0] = "vmess"
+		fields = append(fields, "username="+str(p["uuid"]))
+		if toInt(p["alterId"]) == 0 {
+			fields = append(fields, "vmess-aead=true")
+		}
+		tls = toBool(p["tls"])
+	case "trojan":
+		fields[0] = "trojan"
+		fields = append(fields, "password="+str(p["password"]))
+		tls = true
+	case "hysteria2":
+		if str(p["obfs"]) != "" {
+			return "", "obfs not supported by surge"
+		}
+		fields[0] = "hysteria2"
+		fields = append(fields, "password="+str(p["password"]))
+		tls = true
+	case "tuic":
+		if str(p["uuid"]) == "" {
+			return "", "tuic v4 not supported by surge"
+		}
+		fields[0] = "tuic-v5"
+		fields = append(fields, "password="+str(p["password"]), "uuid="+str(p["uuid"]))
+		tls = true
+	case "snell":
+		fields[0] = "snell"
+		fields = append(fields, "psk="+str(p["psk"]))
+		if version := toInt(p["version"]); version != 0 {
+			fields = append(fields, fmt.Sprintf("version=%d", version))
+		}
+	case "socks5", "http":
+		fields[0] = t
+		if toBool(p["tls"]) {
+			fields[0] += map[string]string{"socks5": "-tls", "http": "s"}[t]
+			tls = true
+		}
+		if user := str(p["username"]); user != "" {
+			fields = append(fields, user, str(p["password"]))
+		}
+	default:
+		return "", "protocol not supported by surge"
+	}
+	if _, ok := p["reality-opts"]; ok {
+		return "", "reality not supported by surge"
+	}
+	if tls {
+		if fields[0] == "vmess" {
+			fields = append(fields, "tls=true")
+		}
+		if s := sni(p); s != "" {
+			fields = append(fields, "sni="+s)
+		}
+		if toBool(p["skip-cert-verify"]) {
+			fields = append(fields, "skip-cert-verify=true")
+		}
+		if alpn := list(p["alpn"]); fields[0] == "tuic-v5" && len(alpn) > 0 {
+			fields = append(fields, "alpn="+alpn[0])
+		}
+	}
+	switch network(p) {
+	case "tcp":
+	case "ws":
+		if fields[0] != "vmess" && fields[0] != "trojan" {
+			return "", "ws not supported by surge for " + fields[0]
+		}
+		fields = append(fields, "ws=true", "ws-path="+str(opts(p, "ws-opts")["path"]))
+		if host := wsHost(p); host != "" {
+			fields = append(fields, "ws-headers=Host:"+host)
+		}
+	default:
+		return "", "network " + network(p) + " not supported by surge"
+	}
+	if t := str(p["type"]); t != "http" && t != "trojan" && toBool(p["udp"]) {
+		fields = append(fields, "udp-relay=true")
+	}
+	return confNameReplacer.Replace(str(p["name"])) + " = " + strings.Join(fields, ", "), ""
+}
+
+func init() {
+	register(&surge{}, "surge", "surfboard")
+}
@@ -0,0 +1,114 @@
+package subconv
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Target 输出格式转换器
+type Target interface {
+	Convert(proxies []map[string]any) ([]byte, []Unsupported)
+}
+
+// Unsupported 目标格式无法表达的节点
+type Unsupported struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (u Unsupported) String() string {
+	return fmt.Sprintf("%s(%s): %s", u.Name, u.Type, u.Reason)
+}
+
+var targets = make(map[string]Target)
+
+func register(t Target, names ...string) {
+	for _, name := range names {
+		targets[strings.ToLower(name)] = t
+	}
+}
+
+// HasTarget 是否存在内置的输出格式
+func HasTarget(target string) bool {
+	_, ok := targets[strings.ToLower(target)]
+	return ok
+}
+
+// Targets 返回所有内置的输出格式名称
+func Targets() []string {
+	names := make([]string, 0, len(targets))
+	for name := range targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Convert 将节点列表转换为目标格式, 没有内置转换器时回退到 Sub-Store
+func Convert(proxies []map[string]any, target string) ([]byte, []Unsupported) {
+	if t, ok := targets[strings.ToLower(target)]; ok {
+		return t.Convert(proxies)
+	}
+	return []byte(ConvertData(string(Marshal(proxies)), target)), nil
+}
+
+func unsupported(p map[string]any, format string, args ...any) Unsupported {
+	return Unsupported{
+		Name:   str(p["name"]),
+		Type:   str(p["type"]),
+		Reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// network 返回节点的传输层, 未设置时为 tcp
+func network(p map[string]any) string {
+	if n := str(p["network"]); n != "" {
+		return n
+	}
+	return "tcp"
+}
+
+func opts(p map[string]any, key string) map[string]any {
+	if m, ok := p[key].(map[string]any); ok {
+		return m
+	}
+	return map[string]any{}
+}
+
+// wsHost 取 ws-opts.headers.Host
+func wsHost(p map[string]any) string {
+	return str(opts(opts(p, "ws-opts"), "headers")["Host"])
+}
+
+func sni(p map[string]any) string {
+	if s := str(p["servername"]); s != "" {
+		return s
+	}
+	return str(p["sni"])
+}
+
+func list(v any) []string {
+	switch t := v.(type) {
+	case []string:
+		return t
+	case []any:
+		result := make([]string, 0, len(t))
+		for _, item := range t {
+			result = append(result, str(item))
+		}
+		return result
+	case string:
+		return splitList(t)
+	default:
+		return nil
+	}
+}
+
+func first(v any) string {
+	if l := list(v); len(l) > 0 {
+		return l[0]
+	}
+	return ""
+}
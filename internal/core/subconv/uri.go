@@ -0,0 +1,771 @@
+package subconv
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+func parseURI(line string) (map[string]any, error) {
+	scheme, _, ok := strings.Cut(line, "://")
+	if !ok {
+		return nil, errors.New("invalid uri")
+	}
+	switch strings.ToLower(scheme) {
+	case "vmess":
+		return parseVmess(line)
+	case "vless":
+		return parseVless(line)
+	case "trojan":
+		return parseTrojan(line)
+	case "ss":
+		return parseSS(line)
+	case "ssr":
+		return parseSSR(line)
+	case "hysteria":
+		return parseHysteria(line)
+	case "hysteria2", "hy2":
+		return parseHysteria2(line)
+	case "tuic":
+		return parseTuic(line)
+	case "socks", "socks5":
+		return parseSocks(line)
+	case "http", "https":
+		return parseHTTP(line)
+	default:
+		return nil, fmt.Errorf("unsupported protocol: %s", scheme)
+	}
+}
+
+// redactPrefix 错误样本中保留的链接正文字符数
+const redactPrefix = 4
+
+// redact 只保留协议与正文开头几个字符, 错误样本会保存并通过接口返回, 不能包含密码与 UUID
+func redact(line string) string {
+	scheme, body, ok := strings.Cut(line, "://")
+	if !ok {
+		scheme, body = "", line
+	} else {
+		scheme += "://"
+	}
+	if len(body) > redactPrefix {
+		body = body[:redactPrefix] + "***"
+	}
+	return scheme + body
+}
+
+// parseURL 解析链接, 去掉 url.Error 中完整的原始链接
+func parseURL(line string) (*url.URL, error) {
+	u, err := url.Parse(line)
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return nil, fmt.Errorf("parse url: %w", urlErr.Err)
+	}
+	return u, err
+}
+
+func parseVmess(line string) (map[string]any, error) {
+	decoded, err := decodeBase64(strings.TrimPrefix(line, "vmess://"))
+	if err != nil {
+		return nil, fmt.Errorf("vmess: %w", err)
+	}
+	var v map[string]any
+	if err := json.Unmarshal(decoded, &v); err != nil {
+		return nil, fmt.Errorf("vmess: %w", err)
+	}
+	cipher := str(v["scy"])
+	if cipher == "" {
+		cipher = "auto"
+	}
+	p := map[string]any{
+		"name":    str(v["ps"]),
+		"type":    "vmess",
+		"server":  str(v["add"]),
+		"port":    toInt(v["port"]),
+		"uuid":    str(v["id"]),
+		"alterId": toInt(v["aid"]),
+		"cipher":  cipher,
+		"udp":     true,
+	}
+	if tls := str(v["tls"]); tls == "tls" || tls == "reality" {
+		p["tls"] = true
+		if sni := str(v["sni"]); sni != "" {
+			p["servername"] = sni
+		}
+		if alpn := splitList(str(v["alpn"])); len(alpn) > 0 {
+			p["alpn"] = alpn
+		}
+		if fp := str(v["fp"]); fp != "" {
+			p["client-fingerprint"] = fp
+		}
+	}
+	network := str(v["net"])
+	if network == "tcp" && str(v["type"]) == "http" {
+		network = "http"
+	}
+	setTransport(p, network, str(v["path"]), str(v["host"]), str(v["path"]))
+	return p, nil
+}
+
+func parseVless(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("vless: %w", err)
+	}
+	q := u.Query()
+	p := map[string]any{
+		"name":   u.Fragment,
+		"type":   "vless",
+		"server": u.Hostname(),
+		"port":   toInt(u.Port()),
+		"uuid":   u.User.Username(),
+		"udp":    true,
+	}
+	if flow := q.Get("flow"); flow != "" {
+		p["flow"] = flow
+	}
+	switch q.Get("security") {
+	case "tls", "xtls":
+		p["tls"] = true
+	case "reality":
+		p["tls"] = true
+		opts := map[string]any{"public-key": q.Get("pbk")}
+		if sid := q.Get("sid"); sid != "" {
+			opts["short-id"] = sid
+		}
+		p["reality-opts"] = opts
+	}
+	setTLS(p, q)
+	setTransport(p, uriNetwork(q), q.Get("path"), q.Get("host"), q.Get("serviceName"))
+	return p, nil
+}
+
+func parseTrojan(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("trojan: %w", err)
+	}
+	q := u.Query()
+	p := map[string]any{
+		"name":     u.Fragment,
+		"type":     "trojan",
+		"server":   u.Hostname(),
+		"port":     toInt(u.Port()),
+		"password": u.User.Username(),
+		"udp":      true,
+	}
+	if q.Get("security") == "reality" {
+		opts := map[string]any{"public-key": q.Get("pbk")}
+		if sid := q.Get("sid"); sid != "" {
+			opts["short-id"] = sid
+		}
+		p["reality-opts"] = opts
+	}
+	setTLS(p, q)
+	setTransport(p, uriNetwork(q), q.Get("path"), q.Get("host"), q.Get("serviceName"))
+	return p, nil
+}
+
+func parseSS(line string) (map[string]any, error) {
+	body := strings.TrimPrefix(line, "ss://")
+	body, name, _ := strings.Cut(body, "#")
+	if n, err := url.PathUnescape(name); err == nil {
+		name = n
+	}
+	if !strings.Contains(body, "@") {
+		main, query, _ := strings.Cut(body, "?")
+		decoded, err := decodeBase64(strings.TrimSuffix(main, "/"))
+		if err != nil {
+			return nil, fmt.Errorf("ss: %w", err)
+		}
+		body = string(decoded)
+		if query != "" {
+			body += "?" + query
+		}
+	}
+	u, err := parseURL("ss://" + body)
+	if err != nil {
+		return nil, fmt.Errorf("ss: %w", err)
+	}
+	cipher := u.User.Username()
+	password, ok := u.User.Password()
+	if !ok {
+		decoded, err := decodeBase64(cipher)
+		if err != nil {
+			return nil, fmt.Errorf("ss: %w", err)
+		}
+		cipher, password, ok = strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, fmt.Errorf("ss: missing field: password")
+		}
+	}
+	p := map[string]any{
+		"name":     name,
+		"type":     "ss",
+		"server":   u.Hostname(),
+		"port":     toInt(u.Port()),
+		"cipher":   cipher,
+		"password": password,
+		"udp":      true,
+	}
+	if plugin := u.Query().Get("plugin"); plugin != "" {
+		setSSPlugin(p, plugin)
+	}
+	return p, nil
+}
+
+func setSSPlugin(p map[string]any, plugin string) {
+	fields := strings.Split(plugin, ";")
+	opts := map[string]any{}
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		opts[k] = v
+	}
+	switch fields[0] {
+	case "obfs-local", "simple-obfs":
+		p["plugin"] = "obfs"
+		p["plugin-opts"] = map[string]any{
+			"mode": opts["obfs"],
+			"host": opts["obfs-host"],
+		}
+	case "v2ray-plugin":
+		pluginOpts := map[string]any{"mode": "websocket"}
+		if _, ok := opts["tls"]; ok {
+			pluginOpts["tls"] = true
+		}
+		if host, ok := opts["host"]; ok {
+			pluginOpts["host"] = host
+		}
+		if path, ok := opts["path"]; ok {
+			pluginOpts["path"] = path
+		}
+		p["plugin"] = "v2ray-plugin"
+		p["plugin-opts"] = pluginOpts
+	default:
+		p["plugin"] = fields[0]
+		p["plugin-opts"] = opts
+	}
+}
+
+func parseSSR(line string) (map[string]any, error) {
+	decoded, err := decodeBase64(strings.TrimPrefix(line, "ssr://"))
+	if err != nil {
+		return nil, fmt.Errorf("ssr: %w", err)
+	}
+	main, query, _ := strings.Cut(string(decoded), "/?")
+	fields := strings.Split(main, ":")
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("ssr: missing field")
+	}
+	n := len(fields)
+	password, err := decodeBase64(fields[n-1])
+	if err != nil {
+		return nil, fmt.Errorf("ssr: %w", err)
+	}
+	p := map[string]any{
+		"type":     "ssr",
+		"server":   strings.Join(fields[:n-5], ":"),
+		"port":     toInt(fields[n-5]),
+		"protocol": fields[n-4],
+		"cipher":   fields[n-3],
+		"obfs":     fields[n-2],
+		"password": string(password),
+		"udp":      true,
+	}
+	q, _ := url.ParseQuery(query)
+	for key, field := range map[string]string{
+		"remarks":    "name",
+		"obfsparam":  "obfs-param",
+		"protoparam": "protocol-param",
+	} {
+		if v := q.Get(key); v != "" {
+			if b, err := decodeBase64(v); err == nil {
+				p[field] = string(b)
+			}
+		}
+	}
+	return p, nil
+}
+
+func parseHysteria(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("hysteria: %w", err)
+	}
+	q := u.Query()
+	p := map[string]any{
+		"name":   u.Fragment,
+		"type":   "hysteria",
+		"server": u.Hostname(),
+		"port":   toInt(u.Port()),
+	}
+	if up := q.Get("upmbps"); up != "" {
+		p["up"] = up
+	}
+	if down := q.Get("downmbps"); down != "" {
+		p["down"] = down
+	}
+	if auth := q.Get("auth"); auth != "" {
+		p["auth-str"] = auth
+	}
+	if protocol := q.Get("protocol"); protocol != "" {
+		p["protocol"] = protocol
+	}
+	if obfs := q.Get("obfs"); obfs != "" {
+		p["obfs"] = obfs
+	}
+	if peer := q.Get("peer"); peer != "" {
+		p["sni"] = peer
+	}
+	if alpn := splitList(q.Get("alpn")); len(alpn) > 0 {
+		p["alpn"] = alpn
+	}
+	if toBool(q.Get("insecure")) {
+		p["skip-cert-verify"] = true
+	}
+	return p, nil
+}
+
+func parseHysteria2(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("hysteria2: %w", err)
+	}
+	q := u.Query()
+	password := u.User.Username()
+	if pass, ok := u.User.Password(); ok {
+		password += ":" + pass
+	}
+	p := map[string]any{
+		"name":     u.Fragment,
+		"type":     "hysteria2",
+		"server":   u.Hostname(),
+		"port":     toInt(u.Port()),
+		"password": password,
+	}
+	if ports := q.Get("mport"); ports != "" {
+		p["ports"] = ports
+	}
+	if obfs := q.Get("obfs"); obfs != "" {
+		p["obfs"] = obfs
+		p["obfs-password"] = q.Get("obfs-password")
+	}
+	if sni := q.Get("sni"); sni != "" {
+		p["sni"] = sni
+	}
+	if toBool(q.Get("insecure")) {
+		p["skip-cert-verify"] = true
+	}
+	return p, nil
+}
+
+func parseTuic(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("tuic: %w", err)
+	}
+	q := u.Query()
+	password, _ := u.User.Password()
+	p := map[string]any{
+		"name":     u.Fragment,
+		"type":     "tuic",
+		"server":   u.Hostname(),
+		"port":     toInt(u.Port()),
+		"uuid":     u.User.Username(),
+		"password": password,
+		"udp":      true,
+	}
+	if cc := q.Get("congestion_control"); cc != "" {
+		p["congestion-controller"] = cc
+	}
+	if mode := q.Get("udp_relay_mode"); mode != "" {
+		p["udp-relay-mode"] = mode
+	}
+	if alpn := splitList(q.Get("alpn")); len(alpn) > 0 {
+		p["alpn"] = alpn
+	}
+	if sni := q.Get("sni"); sni != "" {
+		p["sni"] = sni
+	}
+	if toBool(q.Get("allow_insecure")) || toBool(q.Get("insecure")) {
+		p["skip-cert-verify"] = true
+	}
+	return p, nil
+}
+
+func parseSocks(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("socks: %w", err)
+	}
+	p := map[string]any{
+		"name":   u.Fragment,
+		"type":   "socks5",
+		"server": u.Hostname(),
+		"port":   toInt(u.Port()),
+		"udp":    true,
+	}
+	setUserinfo(p, u)
+	return p, nil
+}
+
+func parseHTTP(line string) (map[string]any, error) {
+	u, err := parseURL(line)
+	if err != nil {
+		return nil, fmt.Errorf("http: %w", err)
+	}
+	p := map[string]any{
+		"name":   u.Fragment,
+		"type":   "http",
+		"server": u.Hostname(),
+		"port":   toInt(u.Port()),
+	}
+	if u.Scheme == "https" {
+		p["tls"] = true
+	}
+	setUserinfo(p, u)
+	return p, nil
+}
+
+// setUserinfo 兼容 v2rayN 将 user:pass 整体 base64 编码的写法
+func setUserinfo(p map[string]any, u *url.URL) {
+	if u.User == nil {
+		return
+	}
+	username := u.User.Username()
+	password, ok := u.User.Password()
+	if !ok {
+		if decoded, err := decodeBase64(username); err == nil {
+			if user, pass, found := strings.Cut(string(decoded), ":"); found {
+				username, password = user, pass
+			}
+		}
+	}
+	if username != "" {
+		p["username"] = username
+	}
+	if password != "" {
+		p["password"] = password
+	}
+}
+
+func setTLS(p map[string]any, q url.Values) {
+	if sni := q.Get("sni"); sni != "" {
+		if p["type"] == "trojan" {
+			p["sni"] = sni
+		} else {
+			p["servername"] = sni
+		}
+	}
+	if fp := q.Get("fp"); fp != "" {
+		p["client-fingerprint"] = fp
+	}
+	if alpn := splitList(q.Get("alpn")); len(alpn) > 0 {
+		p["alpn"] = alpn
+	}
+	if toBool(q.Get("allowInsecure")) || toBool(q.Get("insecure")) {
+		p["skip-cert-verify"] = true
+	}
+}
+
+// uriNetwork 按 xray 分享链接约定转换传输层, type=http 实为 h2
+func uriNetwork(q url.Values) string {
+	network := q.Get("type")
+	switch {
+	case network == "http":
+		return "h2"
+	case network == "tcp" && q.Get("headerType") == "http":
+		return "http"
+	}
+	return network
+}
+
+func setTransport(p map[string]any, network, path, host, serviceName string) {
+	switch network {
+	case "ws", "httpupgrade":
+		opts := map[string]any{}
+		if path != "" {
+			opts["path"] = path
+		}
+		if host != "" {
+			opts["headers"] = map[string]any{"Host": host}
+		}
+		if network == "httpupgrade" {
+			opts["v2ray-http-upgrade"] = true
+		}
+		p["network"] = "ws"
+		p["ws-opts"] = opts
+	case "grpc":
+		p["network"] = "grpc"
+		p["grpc-opts"] = map[string]any{"grpc-service-name": serviceName}
+	case "h2":
+		opts := map[string]any{}
+		if path != "" {
+			opts["path"] = path
+		}
+		if host != "" {
+			opts["host"] = splitList(host)
+		}
+		p["network"] = "h2"
+		p["h2-opts"] = opts
+	case "http":
+		opts := map[string]any{}
+		if path != "" {
+			opts["path"] = splitList(path)
+		}
+		if host != "" {
+			opts["headers"] = map[string]any{"Host": splitList(host)}
+		}
+		p["network"] = "http"
+		p["http-opts"] = opts
+	}
+}
+
+// v2ray 节点分享链接格式, encode 为 true 时整体 base64 编码
+type v2ray struct {
+	encode bool
+}
+
+func (v *v2ray) Convert(proxies []map[string]any) ([]byte, []Unsupported) {
+	var buf strings.Builder
+	var skipped []Unsupported
+	for _, p := range proxies {
+		uri, reason := toURI(p)
+		if uri == "" {
+			skipped = append(skipped, unsupported(p, "%s", reason))
+			continue
+		}
+		buf.WriteString(uri)
+		buf.WriteString("\n")
+	}
+	if !v.encode {
+		return []byte(buf.String()), skipped
+	}
+	return []byte(base64.StdEncoding.EncodeToString([]byte(buf.String()))), skipped
+}
+
+func toURI(p map[string]any) (string, string) {
+	name := url.PathEscape(str(p["name"]))
+	host := net.JoinHostPort(str(p["server"]), strconv.Itoa(toInt(p["port"])))
+	q := url.Values{}
+	switch t := str(p["type"]); t {
+	case "vmess":
+		v := map[string]any{
+			"v":    "2",
+			"ps":   str(p["name"]),
+			"add":  str(p["server"]),
+			"port": strconv.Itoa(toInt(p["port"])),
+			"id":   str(p["uuid"]),
+			"aid":  strconv.Itoa(toInt(p["alterId"])),
+			"scy":  str(p["cipher"]),
+			"net":  network(p),
+			"type": "none",
+		}
+		if toBool(p["tls"]) {
+			v["tls"] = "tls"
+			v["sni"] = sni(p)
+		}
+		switch network(p) {
+		case "ws":
+			v["path"] = str(opts(p, "ws-opts")["path"])
+			v["host"] = wsHost(p)
+		case "grpc":
+			v["path"] = str(opts(p, "grpc-opts")["grpc-service-name"])
+		case "h2":
+			v["path"] = str(opts(p, "h2-opts")["path"])
+			v["host"] = strings.Join(list(opts(p, "h2-opts")["host"]), ",")
+		case "http":
+			v["net"] = "tcp"
+			v["type"] = "http"
+			v["path"] = first(opts(p, "http-opts")["path"])
+			v["host"] = first(opts(opts(p, "http-opts"), "headers")["Host"])
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err.Error()
+		}
+		return "vmess://" + base64.StdEncoding.EncodeToString(out), ""
+	case "vless", "trojan":
+		user := str(p["uuid"])
+		if t == "trojan" {
+			user = str(p["password"])
+		}
+		if flow := str(p["flow"]); flow != "" {
+			q.Set("flow", flow)
+		}
+		if reality, ok := p["reality-opts"].(map[string]any); ok {
+			q.Set("security", "reality")
+			q.Set("pbk", str(reality["public-key"]))
+			if sid := str(reality["short-id"]); sid != "" {
+				q.Set("sid", sid)
+			}
+		} else if t == "trojan" || toBool(p["tls"]) {
+			q.Set("security", "tls")
+		}
+		setURITLS(q, p)
+		if reason := setURITransport(q, p); reason != "" {
+			return "", reason
+		}
+		return fmt.Sprintf("%s://%s@%s?%s#%s", t, url.PathEscape(user), host, q.Encode(), name), ""
+	case "ss":
+		userinfo := base64.RawURLEncoding.EncodeToString([]byte(str(p["cipher"]) + ":" + str(p["password"])))
+		if plugin := str(p["plugin"]); plugin != "" {
+			pluginOpts := opts(p, "plugin-opts")
+			switch plugin {
+			case "obfs":
+				q.Set("plugin", fmt.Sprintf("obfs-local;obfs=%s;obfs-host=%s", str(pluginOpts["mode"]), str(pluginOpts["host"])))
+			case "v2ray-plugin":
+				pluginStr := fmt.Sprintf("v2ray-plugin;mode=websocket;host=%s;path=%s", str(pluginOpts["host"]), str(pluginOpts["path"]))
+				if toBool(pluginOpts["tls"]) {
+					pluginStr += ";tls"
+				}
+				q.Set("plugin", pluginStr)
+			default:
+				return "", "plugin " + plugin + " not supported by uri"
+			}
+			return fmt.Sprintf("ss://%s@%s/?%s#%s", userinfo, host, q.Encode(), name), ""
+		}
+		return fmt.Sprintf("ss://%s@%s#%s", userinfo, host, name), ""
+	case "ssr":
+		b64 := base64.RawURLEncoding.EncodeToString
+		q.Set("remarks", b64([]byte(str(p["name"]))))
+		q.Set("obfsparam", b64([]byte(str(p["obfs-param"]))))
+		q.Set("protoparam", b64([]byte(str(p["protocol-param"]))))
+		main := strings.Join([]string{
+			str(p["server"]),
+			strconv.Itoa(toInt(p["port"])),
+			str(p["protocol"]),
+			str(p["cipher"]),
+			str(p["obfs"]),
+			b64([]byte(str(p["password"]))),
+		}, ":")
+		return "ssr://" + b64([]byte(main+"/?"+q.Encode())), ""
+	case "hysteria":
+		q.Set("auth", str(p["auth-str"]))
+		q.Set("upmbps", str(p["up"]))
+		q.Set("downmbps", str(p["down"]))
+		if protocol := str(p["protocol"]); protocol != "" {
+			q.Set("protocol", protocol)
+		}
+		if obfs := str(p["obfs"]); obfs != "" {
+			q.Set("obfs", obfs)
+		}
+		if s := sni(p); s != "" {
+			q.Set("peer", s)
+		}
+		if alpn := list(p["alpn"]); len(alpn) > 0 {
+			q.Set("alpn", strings.Join(alpn, ","))
+		}
+		if toBool(p["skip-cert-verify"]) {
+			q.Set("insecure", "1")
+		}
+		return fmt.Sprintf("hysteria://%s?%s#%s", host, q.Encode(), name), ""
+	case "hysteria2":
+		if obfs := str(p["obfs"]); obfs != "" {
+			q.Set("obfs", obfs)
+			q.Set("obfs-password", str(p["obfs-password"]))
+		}
+		if ports := str(p["ports"]); ports != "" {
+			q.Set("mport", ports)
+		}
+		if s := sni(p); s != "" {
+			q.Set("sni", s)
+		}
+		if toBool(p["skip-cert-verify"]) {
+			q.Set("insecure", "1")
+		}
+		return fmt.Sprintf("hysteria2://%s@%s?%s#%s", url.PathEscape(str(p["password"])), host, q.Encode(), name), ""
+	case "tuic":
+		if cc := str(p["congestion-controller"]); cc != "" {
+			q.Set("congestion_control", cc)
+		}
+		if mode := str(p["udp-relay-mode"]); mode != "" {
+			q.Set("udp_relay_mode", mode)
+		}
+		if alpn := list(p["alpn"]); len(alpn) > 0 {
+			q.Set("alpn", strings.Join(alpn, ","))
+		}
+		if s := sni(p); s != "" {
+			q.Set("sni", s)
+		}
+		if toBool(p["skip-cert-verify"]) {
+			q.Set("allow_insecure", "1")
+		}
+		userinfo := url.UserPassword(str(p["uuid"]), str(p["password"])).String()
+		return fmt.Sprintf("tuic://%s@%s?%s#%s", userinfo, host, q.Encode(), name), ""
+	case "socks5", "http":
+		scheme := "socks"
+		if t == "http" {
+			scheme = "http"
+			if toBool(p["tls"]) {
+				scheme = "https"
+			}
+		}
+		if user := str(p["username"]); user != "" {
+			userinfo := url.UserPassword(user, str(p["password"])).String()
+			return fmt.Sprintf("%s://%s@%s#%s", scheme, userinfo, host, name), ""
+		}
+		return fmt.Sprintf("%s://%s#%s", scheme, host, name), ""
+	default:
+		return "", "protocol not supported by uri"
+	}
+}
+
+func setURITLS(q url.Values, p map[string]any) {
+	if s := sni(p); s != "" {
+		q.Set("sni", s)
+	}
+	if fp := str(p["client-fingerprint"]); fp != "" {
+		q.Set("fp", fp)
+	}
+	if alpn := list(p["alpn"]); len(alpn) > 0 {
+		q.Set("alpn", strings.Join(alpn, ","))
+	}
+	if toBool(p["skip-cert-verify"]) {
+		q.Set("allowInsecure", "1")
+	}
+}
+
+func setURITransport(q url.Values, p map[string]any) string {
+	switch network(p) {
+	case "tcp":
+		q.Set("type", "tcp")
+	case "ws":
+		ws := opts(p, "ws-opts")
+		q.Set("type", "ws")
+		if toBool(ws["v2ray-http-upgrade"]) {
+			q.Set("type", "httpupgrade")
+		}
+		q.Set("path", str(ws["path"]))
+		if host := wsHost(p); host != "" {
+			q.Set("host", host)
+		}
+	case "grpc":
+		q.Set("type", "grpc")
+		q.Set("serviceName", str(opts(p, "grpc-opts")["grpc-service-name"]))
+	case "h2":
+		h2 := opts(p, "h2-opts")
+		q.Set("type", "http")
+		q.Set("path", str(h2["path"]))
+		q.Set("host", strings.Join(list(h2["host"]), ","))
+	case "http":
+		httpOpts := opts(p, "http-opts")
+		q.Set("type", "tcp")
+		q.Set("headerType", "http")
+		q.Set("path", first(httpOpts["path"]))
+		q.Set("host", first(opts(httpOpts, "headers")["Host"]))
+	default:
+		return "network " + network(p) + " not supported by uri"
+	}
+	return ""
+}
+
+func init() {
+	register(&v2ray{encode: true}, "v2ray", "v2rayn", "base64")
+	register(&v2ray{}, "uri")
+}
@@ -0,0 +1,30 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration003SubHistory 添加订阅拉取记录表
+func Migration003SubHistory() string {
+	return `
+CREATE TABLE IF NOT EXISTS "sub_history" (
+	"id" INTEGER NOT NULL,
+	"sub_id" INTEGER NOT NULL,
+	"run_at" DATETIME NOT NULL,
+	"duration" INTEGER NOT NULL DEFAULT 0,
+	"success" BOOLEAN NOT NULL,
+	"unchanged" BOOLEAN NOT NULL DEFAULT false,
+	"raw_count" INTEGER NOT NULL DEFAULT 0,
+	"new_count" INTEGER NOT NULL DEFAULT 0,
+	"status_code" INTEGER NOT NULL DEFAULT 0,
+	"error" TEXT NOT NULL DEFAULT '',
+	PRIMARY KEY("id"),
+	FOREIGN KEY("sub_id") REFERENCES "sub"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS "idx_sub_history_sub_id_run_at" ON "sub_history" ("sub_id", "run_at");
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202610180900, "dev", "Add Sub History", Migration003SubHistory)
+}
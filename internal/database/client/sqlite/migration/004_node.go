@@ -0,0 +1,24 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration004Node 添加节点池表
+func Migration004Node() string {
+	return `
+CREATE TABLE IF NOT EXISTS "node" (
+	"unique_key" INTEGER NOT NULL,
+	"sub_id" INTEGER NOT NULL,
+	"raw" TEXT NOT NULL,
+	"info" TEXT NOT NULL DEFAULT '{}',
+	"updated_at" DATETIME NOT NULL,
+	PRIMARY KEY("unique_key")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_node_sub_id" ON "node" ("sub_id");
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202610181000, "dev", "Add Node", Migration004Node)
+}
@@ -0,0 +1,22 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration005NodeMark 添加节点固定与黑名单表
+func Migration005NodeMark() string {
+	return `
+CREATE TABLE IF NOT EXISTS "node_mark" (
+	"unique_key" INTEGER NOT NULL,
+	"type" TEXT NOT NULL,
+	"name" TEXT NOT NULL DEFAULT '',
+	"raw" TEXT NOT NULL DEFAULT '',
+	"created_at" DATETIME NOT NULL,
+	PRIMARY KEY("unique_key", "type")
+);
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202610181100, "dev", "Add Node Mark", Migration005NodeMark)
+}
@@ -0,0 +1,20 @@
+package migration
+
+import "github.com/bestruirui/bestsub/internal/database/migration"
+
+// Migration006NodeLabel 添加节点手动标签表
+func Migration006NodeLabel() string {
+	return `
+CREATE TABLE IF NOT EXISTS "node_label" (
+	"unique_key" INTEGER NOT NULL,
+	"label" TEXT NOT NULL,
+	"created_at" DATETIME NOT NULL,
+	PRIMARY KEY("unique_key", "label")
+);
+`
+}
+
+// init 自动注册迁移
+func init() {
+	migration.Register(ClientName, 202610181300, "dev", "Add Node Label", Migration006NodeLabel)
+}
@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type NodeRepository struct {
+	db *DB
+}
+
+func (db *DB) Node() interfaces.NodeRepository {
+	return &NodeRepository{db: db}
+}
+
+// unique_key 以 int64 存储, SQLite 不支持最高位为 1 的 uint64
+
+func (r *NodeRepository) BatchUpsert(ctx context.Context, nodes *[]node.Data) error {
+	log.Debugf("Batch upsert %d nodes", len(*nodes))
+	if len(*nodes) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO node (unique_key, sub_id, raw, info, updated_at)
+	          VALUES (?, ?, ?, ?, ?)
+	          ON CONFLICT(unique_key) DO UPDATE SET
+	          sub_id = excluded.sub_id, raw = excluded.raw, info = excluded.info, updated_at = excluded.updated_at`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, n := range *nodes {
+		info, err := json.Marshal(n.Info)
+		if err != nil {
+			return fmt.Errorf("failed to marshal node info: %w", err)
+		}
+		if _, err := stmt.ExecContext(ctx,
+			int64(n.UniqueKey),
+			n.SubId,
+			string(n.Raw),
+			string(info),
+			now,
+		); err != nil {
+			return fmt.Errorf("failed to upsert node: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeRepository) BatchDelete(ctx context.Context, keys []uint64) error {
+	log.Debugf("Batch delete %d nodes", len(keys))
+	if len(keys) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `DELETE FROM node WHERE unique_key = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, key := range keys {
+		if _, err := stmt.ExecContext(ctx, int64(key)); err != nil {
+			return fmt.Errorf("failed to delete node: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeRepository) List(ctx context.Context) (*[]node.Data, error) {
+	log.Debugf("List node")
+	query := `SELECT unique_key, sub_id, raw, info FROM node`
+
+	rows, err := r.db.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []node.Data
+	for rows.Next() {
+		var (
+			key  int64
+			n    node.Data
+			raw  string
+			info string
+		)
+		if err := rows.Scan(&key, &n.SubId, &raw, &info); err != nil {
+			return nil, fmt.Errorf("failed to scan node: %w", err)
+		}
+		n.UniqueKey = uint64(key)
+		n.Raw = []byte(raw)
+		n.Info = &node.Info{}
+		if err := json.Unmarshal([]byte(info), n.Info); err != nil {
+			log.Warnf("failed to unmarshal node info: %v", err)
+		}
+		nodes = append(nodes, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate nodes: %w", err)
+	}
+
+	return &nodes, nil
+}
@@ -0,0 +1,74 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type NodeLabelRepository struct {
+	db *DB
+}
+
+func (db *DB) NodeLabel() interfaces.NodeLabelRepository {
+	return &NodeLabelRepository{db: db}
+}
+
+func (r *NodeLabelRepository) Create(ctx context.Context, label *node.Label) error {
+	log.Debugf("Create node label")
+	query := `INSERT OR IGNORE INTO node_label (unique_key, label, created_at) VALUES (?, ?, ?)`
+
+	if label.CreatedAt.IsZero() {
+		label.CreatedAt = time.Now()
+	}
+	if _, err := r.db.db.ExecContext(ctx, query, int64(label.Key), label.Label, label.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create node label: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeLabelRepository) Delete(ctx context.Context, key uint64, label string) error {
+	log.Debugf("Delete node label")
+	query := `DELETE FROM node_label WHERE unique_key = ? AND label = ?`
+
+	if _, err := r.db.db.ExecContext(ctx, query, int64(key), label); err != nil {
+		return fmt.Errorf("failed to delete node label: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeLabelRepository) List(ctx context.Context) (*[]node.Label, error) {
+	log.Debugf("List node label")
+	query := `SELECT unique_key, label, created_at FROM node_label ORDER BY created_at`
+
+	rows, err := r.db.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node label: %w", err)
+	}
+	defer rows.Close()
+
+	labels := make([]node.Label, 0)
+	for rows.Next() {
+		var (
+			key   int64
+			label node.Label
+		)
+		if err := rows.Scan(&key, &label.Label, &label.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan node label: %w", err)
+		}
+		label.Key = uint64(key)
+		labels = append(labels, label)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node labels: %w", err)
+	}
+
+	return &labels, nil
+}
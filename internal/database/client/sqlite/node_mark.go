@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type NodeMarkRepository struct {
+	db *DB
+}
+
+func (db *DB) NodeMark() interfaces.NodeMarkRepository {
+	return &NodeMarkRepository{db: db}
+}
+
+func (r *NodeMarkRepository) Create(ctx context.Context, mark *node.Mark) error {
+	log.Debugf("Create node mark")
+	query := `INSERT OR IGNORE INTO node_mark (unique_key, type, name, raw, created_at) VALUES (?, ?, ?, ?, ?)`
+
+	if mark.CreatedAt.IsZero() {
+		mark.CreatedAt = time.Now()
+	}
+	if _, err := r.db.db.ExecContext(ctx, query, int64(mark.Key), mark.Type, mark.Name, string(mark.Raw), mark.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create node mark: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeMarkRepository) Delete(ctx context.Context, key uint64, markType string) error {
+	log.Debugf("Delete node mark")
+	query := `DELETE FROM node_mark WHERE unique_key = ? AND type = ?`
+
+	if _, err := r.db.db.ExecContext(ctx, query, int64(key), markType); err != nil {
+		return fmt.Errorf("failed to delete node mark: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NodeMarkRepository) List(ctx context.Context) (*[]node.Mark, error) {
+	log.Debugf("List node mark")
+	query := `SELECT unique_key, type, name, raw, created_at FROM node_mark ORDER BY created_at DESC`
+
+	rows, err := r.db.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node mark: %w", err)
+	}
+	defer rows.Close()
+
+	marks := make([]node.Mark, 0)
+	for rows.Next() {
+		var (
+			key  int64
+			raw  string
+			mark node.Mark
+		)
+		if err := rows.Scan(&key, &mark.Type, &mark.Name, &raw, &mark.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan node mark: %w", err)
+		}
+		mark.Key = uint64(key)
+		mark.Raw = []byte(raw)
+		marks = append(marks, mark)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node marks: %w", err)
+	}
+
+	return &marks, nil
+}
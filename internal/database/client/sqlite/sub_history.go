@@ -0,0 +1,137 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/sub"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+type SubHistoryRepository struct {
+	db *DB
+}
+
+func (db *DB) SubHistory() interfaces.SubHistoryRepository {
+	return &SubHistoryRepository{db: db}
+}
+
+func (r *SubHistoryRepository) Create(ctx context.Context, history *sub.History) error {
+	log.Debugf("Create sub history")
+	query := `INSERT INTO sub_history (sub_id, run_at, duration, success, unchanged, raw_count, new_count, status_code, error)
+	          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := r.db.db.ExecContext(ctx, query,
+		history.SubID,
+		history.RunAt,
+		history.Duration,
+		history.Success,
+		history.Unchanged,
+		history.RawCount,
+		history.NewCount,
+		history.StatusCode,
+		history.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create sub history: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get sub history id: %w", err)
+	}
+	history.ID = uint64(id)
+
+	return nil
+}
+
+func (r *SubHistoryRepository) List(ctx context.Context, subID uint16, limit int) (*[]sub.History, error) {
+	log.Debugf("List sub history")
+	query := `SELECT id, sub_id, run_at, duration, success, unchanged, raw_count, new_count, status_code, error
+	          FROM sub_history WHERE sub_id = ? ORDER BY run_at DESC LIMIT ?`
+
+	rows, err := r.db.db.QueryContext(ctx, query, subID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sub history: %w", err)
+	}
+	defer rows.Close()
+
+	histories := make([]sub.History, 0)
+	for rows.Next() {
+		var h sub.History
+		err := rows.Scan(
+			&h.ID,
+			&h.SubID,
+			&h.RunAt,
+			&h.Duration,
+			&h.Success,
+			&h.Unchanged,
+			&h.RawCount,
+			&h.NewCount,
+			&h.StatusCode,
+			&h.Error,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sub history: %w", err)
+		}
+		histories = append(histories, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sub history: %w", err)
+	}
+
+	return &histories, nil
+}
+
+func (r *SubHistoryRepository) Stats(ctx context.Context, subID uint16, since time.Time) (*sub.HistoryStats, error) {
+	log.Debugf("Stats sub history")
+	query := `SELECT COUNT(*),
+	                 COALESCE(SUM(success), 0),
+	                 COALESCE(SUM(unchanged), 0),
+	                 COALESCE(AVG(CASE WHEN success AND NOT unchanged THEN raw_count END), 0),
+	                 COALESCE(AVG(CASE WHEN success AND NOT unchanged THEN new_count END), 0),
+	                 COALESCE(AVG(duration), 0)
+	          FROM sub_history WHERE sub_id = ? AND run_at >= ?`
+
+	var stats sub.HistoryStats
+	err := r.db.db.QueryRowContext(ctx, query, subID, since).Scan(
+		&stats.Total,
+		&stats.Success,
+		&stats.Unchanged,
+		&stats.AvgRawCount,
+		&stats.AvgNewCount,
+		&stats.AvgDuration,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stats sub history: %w", err)
+	}
+	stats.Fail = stats.Total - stats.Success
+	if stats.Total > 0 {
+		stats.SuccessRate = float64(stats.Success) / float64(stats.Total)
+	}
+
+	lastQuery := `SELECT run_at FROM sub_history WHERE sub_id = ? AND success = ? ORDER BY run_at DESC LIMIT 1`
+	if err := r.db.db.QueryRowContext(ctx, lastQuery, subID, true).Scan(&stats.LastSuccess); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get last success: %w", err)
+	}
+	if err := r.db.db.QueryRowContext(ctx, lastQuery, subID, false).Scan(&stats.LastFail); err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get last fail: %w", err)
+	}
+
+	return &stats, nil
+}
+
+func (r *SubHistoryRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	log.Debugf("Delete sub history before %v", before)
+	query := `DELETE FROM sub_history WHERE run_at < ?`
+
+	if _, err := r.db.db.ExecContext(ctx, query, before); err != nil {
+		return fmt.Errorf("failed to delete sub history: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/bestruirui/bestsub/internal/models/node"
+)
+
+// NodeRepository 节点池数据访问接口
+type NodeRepository interface {
+	// BatchUpsert 批量写入节点, 已存在的节点更新订阅、配置与测试信息
+	BatchUpsert(ctx context.Context, nodes *[]node.Data) error
+
+	// BatchDelete 根据唯一键批量删除节点
+	BatchDelete(ctx context.Context, keys []uint64) error
+
+	// List 获取全部节点
+	List(ctx context.Context) (*[]node.Data, error)
+}
+
+// NodeMarkRepository 节点固定与黑名单数据访问接口
+type NodeMarkRepository interface {
+	// Create 添加标记, 已存在时忽略
+	Create(ctx context.Context, mark *node.Mark) error
+
+	// Delete 删除标记
+	Delete(ctx context.Context, key uint64, markType string) error
+
+	// List 获取全部标记
+	List(ctx context.Context) (*[]node.Mark, error)
+}
+
+// NodeLabelRepository 节点手动标签数据访问接口
+type NodeLabelRepository interface {
+	// Create 添加标签, 已存在时忽略
+	Create(ctx context.Context, label *node.Label) error
+
+	// Delete 删除标签
+	Delete(ctx context.Context, key uint64, label string) error
+
+	// List 获取全部标签
+	List(ctx context.Context) (*[]node.Label, error)
+}
@@ -12,6 +12,11 @@ type Repository interface {
 	Check() CheckRepository
 
 	Sub() SubRepository
+	SubHistory() SubHistoryRepository
+
+	Node() NodeRepository
+	NodeMark() NodeMarkRepository
+	NodeLabel() NodeLabelRepository
 	Share() ShareRepository
 
 	Storage() StorageRepository
@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+// SubHistoryRepository 订阅拉取记录数据访问接口
+type SubHistoryRepository interface {
+	// Create 添加拉取记录
+	Create(ctx context.Context, history *sub.History) error
+
+	// List 获取订阅最近的拉取记录, 按时间倒序
+	List(ctx context.Context, subID uint16, limit int) (*[]sub.History, error)
+
+	// Stats 统计订阅在指定时间之后的拉取记录
+	Stats(ctx context.Context, subID uint16, since time.Time) (*sub.HistoryStats, error)
+
+	// DeleteBefore 删除指定时间之前的拉取记录
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
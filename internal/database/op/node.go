@@ -0,0 +1,85 @@
+package op
+
+import (
+	"context"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+)
+
+var nodeRepo interfaces.NodeRepository
+
+func NodeRepo() interfaces.NodeRepository {
+	if nodeRepo == nil {
+		nodeRepo = repo.Node()
+	}
+	return nodeRepo
+}
+
+// 节点池本身即为缓存, 此处不再缓存
+
+func GetNodeList(ctx context.Context) ([]nodeModel.Data, error) {
+	nodes, err := NodeRepo().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return *nodes, nil
+}
+
+func SaveNodes(ctx context.Context, nodes []nodeModel.Data) error {
+	return NodeRepo().BatchUpsert(ctx, &nodes)
+}
+
+func DeleteNodes(ctx context.Context, keys []uint64) error {
+	return NodeRepo().BatchDelete(ctx, keys)
+}
+
+var nodeMarkRepo interfaces.NodeMarkRepository
+
+func NodeMarkRepo() interfaces.NodeMarkRepository {
+	if nodeMarkRepo == nil {
+		nodeMarkRepo = repo.NodeMark()
+	}
+	return nodeMarkRepo
+}
+
+func GetNodeMarkList(ctx context.Context) ([]nodeModel.Mark, error) {
+	marks, err := NodeMarkRepo().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return *marks, nil
+}
+
+func CreateNodeMark(ctx context.Context, mark *nodeModel.Mark) error {
+	return NodeMarkRepo().Create(ctx, mark)
+}
+
+func DeleteNodeMark(ctx context.Context, key uint64, markType string) error {
+	return NodeMarkRepo().Delete(ctx, key, markType)
+}
+
+var nodeLabelRepo interfaces.NodeLabelRepository
+
+func NodeLabelRepo() interfaces.NodeLabelRepository {
+	if nodeLabelRepo == nil {
+		nodeLabelRepo = repo.NodeLabel()
+	}
+	return nodeLabelRepo
+}
+
+func GetNodeLabelList(ctx context.Context) ([]nodeModel.Label, error) {
+	labels, err := NodeLabelRepo().List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return *labels, nil
+}
+
+func CreateNodeLabel(ctx context.Context, label *nodeModel.Label) error {
+	return NodeLabelRepo().Create(ctx, label)
+}
+
+func DeleteNodeLabel(ctx context.Context, key uint64, label string) error {
+	return NodeLabelRepo().Delete(ctx, key, label)
+}
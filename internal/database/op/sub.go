@@ -104,6 +104,9 @@ func UpdateSub(ctx context.Context, sub *subModel.Data) error {
 		return fmt.Errorf("sub not found")
 	}
 	sub.Result = oldSub.Result
+	if sub.Config != oldSub.Config {
+		sub.Result = clearResultCache(oldSub.Result)
+	}
 	sub.CreatedAt = oldSub.CreatedAt
 	if err := SubRepo().Update(ctx, sub); err != nil {
 		return err
@@ -124,11 +127,25 @@ func UpdateSubResult(ctx context.Context, id uint16, result subModel.Result) err
 	var oldStatus subModel.Result
 	json.Unmarshal([]byte(sub.Result), &oldStatus)
 
+	if result.Unchanged != 0 {
+		result.RawCount = oldStatus.RawCount
+		result.UniqueCount = oldStatus.UniqueCount
+		result.ParseErrors = oldStatus.ParseErrors
+	}
+	if result.Hash == 0 {
+		result.ETag = oldStatus.ETag
+		result.LastModified = oldStatus.LastModified
+		result.Hash = oldStatus.Hash
+	}
 	result.Success += oldStatus.Success
 	result.Fail += oldStatus.Fail
+	result.Unchanged += oldStatus.Unchanged
 	if result.NodeNullCount != 0 {
 		result.NodeNullCount += oldStatus.NodeNullCount
 	}
+	if result.Userinfo == nil {
+		result.Userinfo = oldStatus.Userinfo
+	}
 	if (result.NodeNullCount > uint16(GetSettingInt(setting.SUB_DISABLE_AUTO))) && GetSettingInt(setting.SUB_DISABLE_AUTO) != 0 {
 		sub.Enable = false
 	}
@@ -143,6 +160,42 @@ func UpdateSubResult(ctx context.Context, id uint16, result subModel.Result) err
 	subCache.Set(id, sub)
 	return nil
 }
+
+// ClearSubResultCache 清除全部订阅的条件请求头与内容哈希, 全局过滤设置变更后下一次拉取将重新过滤
+func ClearSubResultCache(ctx context.Context) error {
+	subs, err := GetSubList(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		result := clearResultCache(sub.Result)
+		if result == sub.Result {
+			continue
+		}
+		sub.Result = result
+		if err := SubRepo().Update(ctx, &sub); err != nil {
+			return err
+		}
+		subCache.Set(sub.ID, sub)
+	}
+	return nil
+}
+
+// clearResultCache 清除条件请求头与内容哈希, 配置变更后的下一次拉取将完整执行
+func clearResultCache(raw string) string {
+	var result subModel.Result
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		return raw
+	}
+	result.ETag = ""
+	result.LastModified = ""
+	result.Hash = 0
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return raw
+	}
+	return string(bytes)
+}
 func DeleteSub(ctx context.Context, id uint16) error {
 	if subCache.Len() == 0 {
 		if err := refreshSubCache(ctx); err != nil {
@@ -0,0 +1,62 @@
+package op
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/database/interfaces"
+	"github.com/bestruirui/bestsub/internal/models/setting"
+	subModel "github.com/bestruirui/bestsub/internal/models/sub"
+)
+
+var subHistoryRepo interfaces.SubHistoryRepository
+
+// lastHistoryPrune 上次清理过期记录的时间, 避免每次写入都执行删除
+var (
+	lastHistoryPrune time.Time
+	pruneMutex       sync.Mutex
+)
+
+func SubHistoryRepo() interfaces.SubHistoryRepository {
+	if subHistoryRepo == nil {
+		subHistoryRepo = repo.SubHistory()
+	}
+	return subHistoryRepo
+}
+
+// AddSubHistory 记录一次拉取结果, 并按保留天数清理过期记录
+func AddSubHistory(ctx context.Context, id uint16, result subModel.Result) error {
+	history := subModel.NewHistory(id, result)
+	if err := SubHistoryRepo().Create(ctx, &history); err != nil {
+		return err
+	}
+	days := GetSettingInt(setting.SUB_HISTORY_RETENTION_DAYS)
+	if days <= 0 {
+		return nil
+	}
+	pruneMutex.Lock()
+	if time.Since(lastHistoryPrune) < time.Hour {
+		pruneMutex.Unlock()
+		return nil
+	}
+	lastHistoryPrune = time.Now()
+	pruneMutex.Unlock()
+	return SubHistoryRepo().DeleteBefore(ctx, time.Now().AddDate(0, 0, -days))
+}
+
+// GetSubHistory 获取订阅最近的拉取记录与保留期内的统计
+func GetSubHistory(ctx context.Context, id uint16, limit int) (*subModel.HistoryResponse, error) {
+	histories, err := SubHistoryRepo().List(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := SubHistoryRepo().Stats(ctx, id, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return &subModel.HistoryResponse{
+		Stats:   *stats,
+		History: *histories,
+	}, nil
+}
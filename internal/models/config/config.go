@@ -5,6 +5,7 @@ type Base struct {
 	Database DatabaseConfig `json:"database"`
 	Log      LogConfig      `json:"log"`
 	JWT      JWTConfig      `json:"jwt"`
+	Sub      SubConfig      `json:"sub"`
 	Session  SessionConfig  `json:"-"`
 }
 
@@ -29,6 +30,11 @@ type JWTConfig struct {
 	Secret string `json:"secret"`
 }
 
+// SubConfig 订阅相关配置
+type SubConfig struct {
+	FileDir string `json:"file_dir"` // 本地文件订阅只能读取该目录下的文件, 相对路径相对于配置文件目录
+}
+
 type SessionConfig struct {
 	NodePath string `json:"-"`
 }
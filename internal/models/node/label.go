@@ -0,0 +1,37 @@
+package node
+
+import "time"
+
+// 标签规则可匹配的字段
+const (
+	LabelFieldName    = "name"
+	LabelFieldServer  = "server"
+	LabelFieldType    = "type"
+	LabelFieldSubName = "sub_name"
+	LabelFieldSubTag  = "sub_tag"
+)
+
+// Label 手动添加的节点标签
+type Label struct {
+	Key       uint64    `json:"key,string" description:"节点唯一键"`
+	Label     string    `json:"label"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LabelRule 按节点名称、服务器或订阅自动添加标签, Match 为正则表达式
+type LabelRule struct {
+	Label string `json:"label"`
+	Field string `json:"field" description:"name/server/type/sub_name/sub_tag"`
+	Match string `json:"match" description:"正则表达式"`
+}
+
+// LabelRequest 为节点手动添加标签
+type LabelRequest struct {
+	Labels []string `json:"labels" binding:"required"`
+}
+
+// LabelCount 标签及其在节点池中的节点数
+type LabelCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,28 @@
+package node
+
+import "time"
+
+const (
+	MarkPin       = "pin"
+	MarkBlacklist = "blacklist"
+)
+
+// Mark 节点标记, 固定的节点不会被淘汰, 黑名单中的节点不会再加入节点池
+type Mark struct {
+	Key       uint64    `json:"key,string" description:"节点唯一键"`
+	Type      string    `json:"type" description:"标记类型: pin/blacklist"`
+	Name      string    `json:"name" description:"标记时的节点名称"`
+	CreatedAt time.Time `json:"created_at" description:"标记时间"`
+	Raw       []byte    `json:"-"` // 标记时的节点配置, 去重方式变化时用于重新计算唯一键
+}
+
+// AddRequest 手动添加节点
+type AddRequest struct {
+	Content string `json:"content" binding:"required" description:"分享链接、YAML 片段或任意支持的订阅内容"`
+}
+
+type AddResponse struct {
+	Parsed uint32   `json:"parsed" description:"解析成功的节点数"`
+	Queued uint32   `json:"queued" description:"进入测试队列的节点数, 测试通过后加入节点池"`
+	Errors []string `json:"errors" description:"解析或校验失败的信息"`
+}
@@ -2,6 +2,8 @@ package node
 
 import (
 	"encoding/json"
+	"slices"
+	"time"
 
 	"github.com/bestruirui/bestsub/internal/utils/generic"
 	"github.com/cespare/xxhash/v2"
@@ -16,7 +18,8 @@ const (
 
 type Data struct {
 	Base
-	Info *Info
+	Info   *Info    // 节点池内的 Info 只在池锁内修改, 池外拿到的都是快照
+	Labels []string // 手动与规则添加的标签, 仅保存在内存中, 变化时整体替换
 }
 
 type Base struct {
@@ -43,6 +46,17 @@ type Info struct {
 	AliveStatus uint64
 	IP          uint32
 	Country     string
+	AddedAt     int64 // 入池时间(unix 秒)
+	Score       uint8 // 节点池策略计算的分数(0-100)
+
+	Capabilities []string // 探测检测得到的能力, 有序且不重复, 修改时整体替换
+
+	ConsecutiveSuccess uint16 // 连续存活次数
+	ConsecutiveFail    uint16 // 连续失败次数
+	LastAlive          int64  // 最近一次存活时间(unix 秒)
+	Flaps              uint16 // 抖动窗口内存活状态切换次数
+	FlapSince          int64  // 抖动窗口开始时间(unix 秒)
+	QuarantineUntil    int64  // 隔离截止时间(unix 秒)
 }
 
 type SimpleInfo struct {
@@ -51,18 +65,43 @@ type SimpleInfo struct {
 	Delay     uint16 `json:"delay"`
 	Risk      uint8  `json:"risk"`
 	Count     uint32 `json:"count"`
+	Alive     uint32 `json:"alive"`
+	Score     uint8  `json:"score,omitempty"`
 }
 
 type Filter struct {
-	SubId         []uint16 `json:"sub_id"`
-	SubIdExclude  bool     `json:"sub_id_exclude"`
-	SpeedUpMore   uint32   `json:"speed_up_more"`
-	SpeedDownMore uint32   `json:"speed_down_more"`
-	Country       []string `json:"country"`
-	CountryExclude bool    `json:"country_exclude"`
-	DelayLessThan uint16   `json:"delay_less_than"`
-	AliveStatus   uint64   `json:"alive_status"`
-	RiskLessThan  uint8    `json:"risk_less_than"`
+	SubId          []uint16 `json:"sub_id" form:"sub_id"`
+	SubIdExclude   bool     `json:"sub_id_exclude" form:"sub_id_exclude"`
+	SubTag         []string `json:"sub_tag" form:"sub_tag" description:"订阅含任一标签, 生成分享时解析"`
+	SubTagExclude  []string `json:"sub_tag_exclude" form:"sub_tag_exclude" description:"订阅不含任何标签"`
+	SpeedUpMore    uint32   `json:"speed_up_more" form:"speed_up_more"`
+	SpeedDownMore  uint32   `json:"speed_down_more" form:"speed_down_more"`
+	Country        []string `json:"country" form:"country"`
+	CountryExclude bool     `json:"country_exclude" form:"country_exclude"`
+	DelayLessThan  uint16   `json:"delay_less_than" form:"delay_less_than"`
+	AliveStatus    uint64   `json:"alive_status" form:"alive_status"`
+	RiskLessThan   uint8    `json:"risk_less_than" form:"risk_less_than"`
+
+	Label        []string `json:"label" form:"label" description:"包含任一标签"`
+	LabelExclude []string `json:"label_exclude" form:"label_exclude" description:"不包含任何标签"`
+
+	Capability        []string `json:"capability" form:"capability" description:"具备全部能力"`
+	CapabilityExclude []string `json:"capability_exclude" form:"capability_exclude" description:"不具备任何能力"`
+
+	IncludeQuarantined bool `json:"include_quarantined" form:"include_quarantined"`
+}
+
+// Overlap 订阅之间的节点重复情况
+type Overlap struct {
+	Subs   []OverlapSub `json:"subs"`
+	Matrix [][]uint32   `json:"matrix" description:"matrix[i][j] 为 subs[i] 与 subs[j] 共有的节点数, 对角线为订阅的节点总数"`
+}
+
+type OverlapSub struct {
+	ID     uint16 `json:"id" description:"订阅ID"`
+	Name   string `json:"name" description:"订阅名称"`
+	Total  uint32 `json:"total" description:"去重后的节点数"`
+	Unique uint32 `json:"unique" description:"仅由该订阅提供的节点数"`
 }
 
 func (i *Info) SetAliveStatus(AliveStatus uint64, status bool) {
@@ -73,6 +112,68 @@ func (i *Info) SetAliveStatus(AliveStatus uint64, status bool) {
 	}
 }
 
+// RecordAlive 更新连续成功/失败次数, 存活状态变化时计入一次抖动
+func (i *Info) RecordAlive(alive bool, now time.Time) {
+	wasAlive := i.AliveStatus&Alive != 0
+	if wasAlive != alive && (i.ConsecutiveSuccess > 0 || i.ConsecutiveFail > 0) {
+		i.Flaps++
+	}
+	if alive {
+		i.ConsecutiveSuccess++
+		i.ConsecutiveFail = 0
+		i.LastAlive = now.Unix()
+	} else {
+		i.ConsecutiveFail++
+		i.ConsecutiveSuccess = 0
+	}
+	i.SetAliveStatus(Alive, alive)
+}
+
+// Clone 深拷贝测试信息, 用于在节点池锁外读取
+func (i *Info) Clone() *Info {
+	c := *i
+	c.SpeedUp = i.SpeedUp.Clone()
+	c.SpeedDown = i.SpeedDown.Clone()
+	c.Delay = i.Delay.Clone()
+	c.Capabilities = slices.Clone(i.Capabilities)
+	return &c
+}
+
+// SetCapability 设置或清除能力, 总是替换为新的切片, 已取出的快照不受影响
+func (i *Info) SetCapability(name string, status bool) {
+	pos, ok := slices.BinarySearch(i.Capabilities, name)
+	if ok == status {
+		return
+	}
+	if status {
+		i.Capabilities = slices.Insert(slices.Clone(i.Capabilities), pos, name)
+	} else {
+		i.Capabilities = slices.Delete(slices.Clone(i.Capabilities), pos, pos+1)
+	}
+}
+
+// SetCapabilities 将能力整体替换为 names, 不在其中的能力被清除
+func (i *Info) SetCapabilities(names []string) {
+	names = slices.Compact(slices.Sorted(slices.Values(names)))
+	if slices.Equal(i.Capabilities, names) {
+		return
+	}
+	if len(names) == 0 {
+		names = nil
+	}
+	i.Capabilities = names
+}
+
+func (i *Info) HasCapability(name string) bool {
+	_, ok := slices.BinarySearch(i.Capabilities, name)
+	return ok
+}
+
+// Quarantined 节点是否处于隔离期
+func (i *Info) Quarantined() bool {
+	return i.QuarantineUntil > time.Now().Unix()
+}
+
 func (u *UniqueKey) Gen() uint64 {
 	bytes, _ := json.Marshal(u)
 	return xxhash.Sum64(bytes)
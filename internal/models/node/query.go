@@ -0,0 +1,174 @@
+package node
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bestruirui/bestsub/internal/utils"
+)
+
+// Query 节点列表查询参数
+type Query struct {
+	Filter
+	Search   string `form:"search" description:"按名称或服务器地址搜索, 不区分大小写"`
+	Sort     string `form:"sort" description:"排序字段: delay/speed_down/speed_up/risk/country/score/name"`
+	Desc     bool   `form:"desc" description:"是否降序"`
+	Page     int    `form:"page" description:"页码, 从 1 开始"`
+	PageSize int    `form:"page_size" description:"每页数量, 默认 50"`
+}
+
+type Page struct {
+	Total int    `json:"total" description:"符合条件的节点总数"`
+	List  []Item `json:"list"`
+}
+
+// Item 节点列表项
+type Item struct {
+	Key          uint64   `json:"key,string" description:"节点唯一键"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Server       string   `json:"server"`
+	Port         int      `json:"port"`
+	SubID        uint16   `json:"sub_id"`
+	SubName      string   `json:"sub_name"`
+	Delay        uint16   `json:"delay" description:"平均延迟(毫秒)"`
+	SpeedUp      uint32   `json:"speed_up" description:"平均上传速度(KB/s)"`
+	SpeedDown    uint32   `json:"speed_down" description:"平均下载速度(KB/s)"`
+	Risk         uint8    `json:"risk"`
+	Country      string   `json:"country"`
+	IP           string   `json:"ip"`
+	Alive        bool     `json:"alive"`
+	Score        uint8    `json:"score"`
+	Quarantined  bool     `json:"quarantined"`
+	Pinned       bool     `json:"pinned" description:"是否固定, 固定的节点不会被淘汰"`
+	Labels       []string `json:"labels"`
+	Capabilities []string `json:"capabilities" description:"探测检测得到的能力"`
+}
+
+// Detail 节点详情
+type Detail struct {
+	Item
+	Raw                map[string]any `json:"raw" description:"节点配置"`
+	DelayHistory       []uint16       `json:"delay_history" description:"延迟记录, 65535 表示检测失败"`
+	SpeedUpHistory     []uint32       `json:"speed_up_history"`
+	SpeedDownHistory   []uint32       `json:"speed_down_history"`
+	AliveStatus        uint64         `json:"alive_status"`
+	AliveStatusNames   []string       `json:"alive_status_names" description:"alive_status 中已置位的检测项"`
+	ConsecutiveSuccess uint16         `json:"consecutive_success"`
+	ConsecutiveFail    uint16         `json:"consecutive_fail"`
+	Flaps              uint16         `json:"flaps"`
+	AddedAt            time.Time      `json:"added_at"`
+	LastAlive          time.Time      `json:"last_alive"`
+	QuarantineUntil    time.Time      `json:"quarantine_until"`
+	OfferedBy          []uint16       `json:"offered_by" description:"提供该节点的全部订阅"`
+}
+
+// StatusNames alive_status 各位的名称
+var StatusNames = []struct {
+	Bit  uint64
+	Name string
+}{
+	{Alive, "alive"},
+	{Country, "country"},
+	{TikTok, "tiktok"},
+	{TikTokIDC, "tiktok_idc"},
+}
+
+// DecodeStatus 返回已置位的检测项名称
+func DecodeStatus(status uint64) []string {
+	names := make([]string, 0)
+	for _, s := range StatusNames {
+		if status&s.Bit != 0 {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// Fields 节点配置中用于展示与搜索的字段
+type Fields struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Server string `json:"server"`
+	Port   int    `json:"port"`
+}
+
+func (d *Data) Fields() Fields {
+	var f Fields
+	json.Unmarshal(d.Raw, &f)
+	return f
+}
+
+func (d *Data) GenItem(subName string) Item {
+	f := d.Fields()
+	return Item{
+		Key:          d.UniqueKey,
+		Name:         f.Name,
+		Type:         f.Type,
+		Server:       f.Server,
+		Port:         f.Port,
+		SubID:        d.SubId,
+		SubName:      subName,
+		Delay:        d.Info.Delay.Average(),
+		SpeedUp:      d.Info.SpeedUp.Average(),
+		SpeedDown:    d.Info.SpeedDown.Average(),
+		Risk:         d.Info.Risk,
+		Country:      d.Info.Country,
+		IP:           utils.Uint32ToIP(d.Info.IP),
+		Alive:        d.Info.AliveStatus&Alive != 0,
+		Score:        d.Info.Score,
+		Quarantined:  d.Info.Quarantined(),
+		Labels:       d.Labels,
+		Capabilities: d.Info.Capabilities,
+	}
+}
+
+func (d *Data) GenDetail(subName string, offeredBy []uint16) Detail {
+	var raw map[string]any
+	json.Unmarshal(d.Raw, &raw)
+	return Detail{
+		Item:               d.GenItem(subName),
+		Raw:                raw,
+		DelayHistory:       d.Info.Delay.GetAll(),
+		SpeedUpHistory:     d.Info.SpeedUp.GetAll(),
+		SpeedDownHistory:   d.Info.SpeedDown.GetAll(),
+		AliveStatus:        d.Info.AliveStatus,
+		AliveStatusNames:   DecodeStatus(d.Info.AliveStatus),
+		ConsecutiveSuccess: d.Info.ConsecutiveSuccess,
+		ConsecutiveFail:    d.Info.ConsecutiveFail,
+		Flaps:              d.Info.Flaps,
+		AddedAt:            unixTime(d.Info.AddedAt),
+		LastAlive:          unixTime(d.Info.LastAlive),
+		QuarantineUntil:    unixTime(d.Info.QuarantineUntil),
+		OfferedBy:          offeredBy,
+	}
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// Resize 节点池容量调整结果
+type Resize struct {
+	OldSize int `json:"old_size" description:"调整前容量"`
+	NewSize int `json:"new_size" description:"调整后容量"`
+	Count   int `json:"count" description:"调整后节点数"`
+	Evicted int `json:"evicted" description:"缩小容量时按排名淘汰的节点数"`
+}
+
+// Rekey 去重方式变化后重新计算唯一键的结果
+type Rekey struct {
+	Config  string `json:"config" description:"去重方式, 预设名称或字段列表"`
+	Count   int    `json:"count" description:"调整后节点数"`
+	Changed int    `json:"changed" description:"唯一键发生变化的节点数"`
+	Merged  int    `json:"merged" description:"唯一键相同而被合并的节点数"`
+}
+
+// PoolUpdate 修改配置项后节点池立即发生的变化
+type PoolUpdate struct {
+	Resize *Resize `json:"resize,omitempty"`
+	Rekey  *Rekey  `json:"rekey,omitempty"`
+}
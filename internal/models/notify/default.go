@@ -4,5 +4,6 @@ func DefaultTemplates() []Template {
 	return []Template{
 		// 	{"login_success", "登录成功", "{{.Username}}{{.Time}}{{.IP}}{{.UserAgent}}"},
 		// 	{"login_failed", "登录失败", "{{.Username}}{{.Time}}{{.IP}}{{.UserAgent}}"},
+		{Type: "sub_quota", Template: "{{.Name}}: {{.Msg}} 已用 {{.Used}} / {{.Total}} 到期 {{.Expire}}"},
 	}
 }
@@ -41,11 +41,13 @@ type Instance interface {
 const (
 	TypeLoginSuccess uint16 = 1 << 0 // 登录成功通知
 	TypeLoginFailed  uint16 = 1 << 1 // 登录失败通知
+	TypeSubQuota     uint16 = 1 << 2 // 订阅流量或到期提醒
 )
 
 var TypeMap = map[uint16]string{
 	TypeLoginSuccess: "login_success",
 	TypeLoginFailed:  "login_failed",
+	TypeSubQuota:     "sub_quota",
 }
 
 func (c *Request) GenData(id uint16) Data {
@@ -27,10 +27,90 @@ func DefaultSetting() []Setting {
 			Key:   SUB_DISABLE_AUTO,
 			Value: "0",
 		},
+		{
+			Key:   SUB_QUOTA_WARN_PERCENT,
+			Value: "90",
+		},
+		{
+			Key:   SUB_EXPIRE_WARN_DAYS,
+			Value: "3",
+		},
+		{
+			Key:   SUB_RETRY_BACKOFF,
+			Value: "1000",
+		},
+		{
+			Key:   SUB_RETRY_BACKOFF_MAX,
+			Value: "30000",
+		},
+		{
+			Key:   SUB_RETRY_STATUS,
+			Value: "408,425,429,500,502,503,504",
+		},
+		{
+			Key:   SUB_BREAKER_THRESHOLD,
+			Value: "5",
+		},
+		{
+			Key:   SUB_BREAKER_COOLDOWN,
+			Value: "3600",
+		},
+		{
+			Key:   SUB_HISTORY_RETENTION_DAYS,
+			Value: "30",
+		},
+		{
+			Key:   SUB_SCORE_POLICY,
+			Value: "none",
+		},
+		{
+			Key:   SUB_SCORE_THRESHOLD,
+			Value: "20",
+		},
+		{
+			Key:   SUB_SCORE_SLOW_FACTOR,
+			Value: "4",
+		},
 		{
 			Key:   NODE_POOL_SIZE,
 			Value: "1000",
 		},
+		{
+			Key:   NODE_POOL_POLICY,
+			Value: "delay",
+		},
+		{
+			Key:   NODE_DEDUPE_KEY,
+			Value: "default",
+		},
+		{
+			Key:   NODE_LABEL_RULES,
+			Value: "[]",
+		},
+		{
+			Key:   NODE_SCORE_WEIGHTS,
+			Value: `{"delay":30,"speed":25,"alive":20,"risk":10,"country":10,"age":5}`,
+		},
+		{
+			Key:   NODE_EVICT_FAIL_COUNT,
+			Value: "0",
+		},
+		{
+			Key:   NODE_EVICT_DEAD_HOURS,
+			Value: "0",
+		},
+		{
+			Key:   NODE_FLAP_THRESHOLD,
+			Value: "0",
+		},
+		{
+			Key:   NODE_FLAP_WINDOW,
+			Value: "24",
+		},
+		{
+			Key:   NODE_QUARANTINE_HOURS,
+			Value: "12",
+		},
 		{
 			Key:   NODE_TEST_URL,
 			Value: "https://www.gstatic.com/generate_204",
@@ -51,6 +131,10 @@ func DefaultSetting() []Setting {
 			Key:   NODE_PROTOCOL_FILTER,
 			Value: "",
 		},
+		{
+			Key:   NODE_FIELD_FILTER,
+			Value: "[]",
+		},
 		{
 			Key:   TASK_MAX_THREAD,
 			Value: "200",
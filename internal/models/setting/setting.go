@@ -16,7 +16,23 @@ const (
 
 	SUB_DISABLE_AUTO = "sub_disable_auto"
 
+	SUB_QUOTA_WARN_PERCENT = "sub_quota_warn_percent"
+	SUB_EXPIRE_WARN_DAYS   = "sub_expire_warn_days"
+
+	SUB_RETRY_BACKOFF     = "sub_retry_backoff"
+	SUB_RETRY_BACKOFF_MAX = "sub_retry_backoff_max"
+	SUB_RETRY_STATUS      = "sub_retry_status"
+	SUB_BREAKER_THRESHOLD = "sub_breaker_threshold"
+	SUB_BREAKER_COOLDOWN  = "sub_breaker_cooldown"
+
+	SUB_HISTORY_RETENTION_DAYS = "sub_history_retention_days"
+
+	SUB_SCORE_POLICY      = "sub_score_policy"
+	SUB_SCORE_THRESHOLD   = "sub_score_threshold"
+	SUB_SCORE_SLOW_FACTOR = "sub_score_slow_factor"
+
 	NODE_POOL_SIZE    = "node_pool_size"
+	NODE_POOL_POLICY  = "node_pool_policy"
 	NODE_TEST_URL     = "node_test_url"
 	NODE_TEST_TIMEOUT = "node_test_timeout"
 
@@ -24,6 +40,20 @@ const (
 	NODE_PROTOCOL_FILTER_MODE   = "node_protocol_filter_mode"
 	NODE_PROTOCOL_FILTER        = "node_protocol_filter"
 
+	NODE_FIELD_FILTER = "node_field_filter"
+
+	NODE_DEDUPE_KEY = "node_dedupe_key"
+
+	NODE_LABEL_RULES = "node_label_rules"
+
+	NODE_SCORE_WEIGHTS = "node_score_weights"
+
+	NODE_EVICT_FAIL_COUNT = "node_evict_fail_count"
+	NODE_EVICT_DEAD_HOURS = "node_evict_dead_hours"
+	NODE_FLAP_THRESHOLD   = "node_flap_threshold"
+	NODE_FLAP_WINDOW      = "node_flap_window"
+	NODE_QUARANTINE_HOURS = "node_quarantine_hours"
+
 	TASK_MAX_THREAD  = "task_max_thread"
 	TASK_MAX_TIMEOUT = "task_max_timeout"
 	TASK_MAX_RETRY   = "task_max_retry"
@@ -0,0 +1,58 @@
+package sub
+
+import "time"
+
+// History 单次拉取记录
+type History struct {
+	ID         uint64    `db:"id" json:"id"`
+	SubID      uint16    `db:"sub_id" json:"sub_id"`
+	RunAt      time.Time `db:"run_at" json:"run_at" description:"运行时间"`
+	Duration   uint16    `db:"duration" json:"duration" description:"运行时长(单位:毫秒)"`
+	Success    bool      `db:"success" json:"success" description:"是否成功"`
+	Unchanged  bool      `db:"unchanged" json:"unchanged" description:"内容是否未变化"`
+	RawCount   uint32    `db:"raw_count" json:"raw_count" description:"节点数量"`
+	NewCount   uint32    `db:"new_count" json:"new_count" description:"新增待测节点数量"`
+	StatusCode int       `db:"status_code" json:"status_code" description:"HTTP 状态码, 非 HTTP 来源为 0"`
+	Error      string    `db:"error" json:"error,omitempty" description:"错误信息"`
+}
+
+// HistoryStats 拉取记录统计
+type HistoryStats struct {
+	Total       uint32    `json:"total" description:"运行次数"`
+	Success     uint32    `json:"success" description:"成功次数"`
+	Fail        uint32    `json:"fail" description:"失败次数"`
+	Unchanged   uint32    `json:"unchanged" description:"内容未变化次数"`
+	SuccessRate float64   `json:"success_rate" description:"成功率(0-1), 未变化计为成功"`
+	AvgRawCount float64   `json:"avg_raw_count" description:"成功拉取的平均节点数量"`
+	AvgNewCount float64   `json:"avg_new_count" description:"成功拉取的平均新增节点数量"`
+	AvgDuration float64   `json:"avg_duration" description:"平均运行时长(单位:毫秒)"`
+	LastSuccess time.Time `json:"last_success" description:"最近一次成功时间"`
+	LastFail    time.Time `json:"last_fail" description:"最近一次失败时间"`
+}
+
+type HistoryResponse struct {
+	Stats   HistoryStats `json:"stats"`
+	History []History    `json:"history"`
+}
+
+// NewHistory 根据单次拉取结果生成记录
+func NewHistory(subID uint16, result Result) History {
+	return History{
+		SubID:      subID,
+		RunAt:      result.LastRun,
+		Duration:   result.Duration,
+		Success:    result.Fail == 0,
+		Unchanged:  result.Unchanged != 0,
+		RawCount:   result.RawCount,
+		NewCount:   result.NewCount,
+		StatusCode: result.StatusCode,
+		Error:      failMsg(result),
+	}
+}
+
+func failMsg(result Result) string {
+	if result.Fail == 0 {
+		return ""
+	}
+	return result.Msg
+}
@@ -0,0 +1,37 @@
+package sub
+
+import nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+
+// scoreDelayBase 平均延迟达到该值(毫秒)时速度得分为 0
+const scoreDelayBase = 2000
+
+// Score 订阅质量评分(0-100), 由以下比例加权得到:
+//   - 去重率 20%: 去重后的节点数 / 节点数
+//   - 入池率 40%: 池中节点数 / 去重后的节点数
+//   - 存活率 25%: 池中存活节点数 / 池中节点数
+//   - 速度 15%: 池中节点平均延迟越低越高
+//
+// 上次拉取未获得节点时为 0
+func (r *Result) Score(info nodeModel.SimpleInfo) uint8 {
+	if r.RawCount == 0 {
+		return 0
+	}
+	unique := r.UniqueCount
+	if unique == 0 || unique > r.RawCount {
+		unique = r.RawCount
+	}
+	uniqueRate := float64(unique) / float64(r.RawCount)
+	admitRate := min(float64(info.Count)/float64(unique), 1)
+	var aliveRate, speed float64
+	if info.Count > 0 {
+		aliveRate = float64(info.Alive) / float64(info.Count)
+		speed = max(1-float64(info.Delay)/scoreDelayBase, 0)
+	}
+	return uint8(100 * (0.2*uniqueRate + 0.4*admitRate + 0.25*aliveRate + 0.15*speed))
+}
+
+// ScoreInfo 返回附带质量评分的订阅信息
+func (r *Result) ScoreInfo(info nodeModel.SimpleInfo) nodeModel.SimpleInfo {
+	info.Score = r.Score(info)
+	return info
+}
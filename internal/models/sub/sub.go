@@ -2,6 +2,7 @@ package sub
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
@@ -19,23 +20,102 @@ type Data struct {
 	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
 }
 
+const (
+	SourceUrl     = "url"
+	SourceFile    = "file"
+	SourceContent = "content"
+)
+
 type Config struct {
-	Url                  string   `json:"url"`
-	Proxy                bool     `json:"proxy"`
-	Timeout              int      `json:"timeout"`
-	ProtocolFilterEnable bool     `json:"protocol_filter_enable"`
-	ProtocolFilterMode   bool     `json:"protocol_filter_mode"`
-	ProtocolFilter       []string `json:"protocol_filter"`
+	Type                 string            `json:"type" description:"订阅来源: url/file/content"`
+	Url                  string            `json:"url" description:"订阅链接, file 类型为订阅文件目录下的路径, 支持 file:// 前缀、目录与通配符"`
+	Content              string            `json:"content" description:"content 类型的订阅内容"`
+	Method               string            `json:"method" description:"请求方法, 默认 GET"`
+	UserAgent            string            `json:"user_agent" description:"User-Agent 预设(clash-meta/v2rayn/sing-box 等)或自定义字符串"`
+	Headers              map[string]string `json:"headers" description:"额外请求头"`
+	AuthType             string            `json:"auth_type" description:"认证方式: basic/bearer"`
+	Username             string            `json:"username" description:"basic 认证用户名"`
+	Password             string            `json:"password" description:"basic 认证密码"`
+	Token                string            `json:"token" description:"bearer 认证令牌"`
+	Proxy                bool              `json:"proxy"`
+	ProxyNode            *nodeModel.Filter `json:"proxy_node,omitempty" description:"经节点池中满足条件的节点下载, 为空时不使用"`
+	ProxyNodeLimit       int               `json:"proxy_node_limit,omitempty" description:"最多尝试的候选节点数, 默认 3"`
+	Timeout              int               `json:"timeout"`
+	Retry                int               `json:"retry" description:"最大尝试次数, 0 使用全局设置"`
+	RetryBackoff         int               `json:"retry_backoff" description:"重试退避基数(毫秒), 0 使用全局设置"`
+	RetryStatus          []int             `json:"retry_status" description:"可重试的状态码, 为空使用全局设置"`
+	ProtocolFilterEnable bool              `json:"protocol_filter_enable"`
+	ProtocolFilterMode   bool              `json:"protocol_filter_mode"`
+	ProtocolFilter       []string          `json:"protocol_filter"`
+	NodeFilter           []NodeFilter      `json:"node_filter" description:"节点字段过滤规则"`
+}
+
+// NodeFilter 节点字段过滤规则, 多条规则需同时满足
+type NodeFilter struct {
+	Field   string `json:"field" description:"匹配字段: name/server/port/network/cipher"`
+	Pattern string `json:"pattern" description:"正则表达式"`
+	Exclude bool   `json:"exclude" description:"true 丢弃匹配的节点, false 仅保留匹配的节点"`
 }
 
 type Result struct {
-	Success       uint16    `json:"success,omitempty" description:"成功次数"`
-	Fail          uint16    `json:"fail,omitempty" description:"失败次数"`
-	NodeNullCount uint16    `json:"node_null_count,omitempty" description:"节点为空次数"`
-	Msg           string    `json:"msg,omitempty" description:"消息"`
-	RawCount      uint32    `json:"raw_count,omitempty" description:"节点数量"`
-	LastRun       time.Time `json:"last_run,omitempty" description:"上次运行时间"`
-	Duration      uint16    `json:"duration,omitempty" description:"运行时长(单位:毫秒)"`
+	Success       uint16       `json:"success,omitempty" description:"成功次数"`
+	Fail          uint16       `json:"fail,omitempty" description:"失败次数"`
+	Unchanged     uint16       `json:"unchanged,omitempty" description:"内容未变化次数"`
+	NodeNullCount uint16       `json:"node_null_count,omitempty" description:"节点为空次数"`
+	Msg           string       `json:"msg,omitempty" description:"消息"`
+	RawCount      uint32       `json:"raw_count,omitempty" description:"节点数量"`
+	UniqueCount   uint32       `json:"unique_count,omitempty" description:"去重后的节点数量"`
+	NewCount      uint32       `json:"new_count,omitempty" description:"新增待测节点数量"`
+	StatusCode    int          `json:"status_code,omitempty" description:"HTTP 状态码"`
+	LastRun       time.Time    `json:"last_run,omitempty" description:"上次运行时间"`
+	Duration      uint16       `json:"duration,omitempty" description:"运行时长(单位:毫秒)"`
+	Userinfo      *Userinfo    `json:"userinfo,omitempty" description:"订阅流量与到期信息"`
+	ETag          string       `json:"etag,omitempty" description:"上次响应的 ETag"`
+	LastModified  string       `json:"last_modified,omitempty" description:"上次响应的 Last-Modified"`
+	Hash          uint64       `json:"hash,omitempty" description:"上次订阅内容的哈希"`
+	ParseErrors   []ParseError `json:"parse_errors,omitempty" description:"上次拉取中解析或校验失败的节点, 按错误类型汇总"`
+}
+
+const (
+	ParseErrorUnsupported  = "unsupported_protocol"
+	ParseErrorCipher       = "bad_cipher"
+	ParseErrorMissingField = "missing_field"
+	ParseErrorFormat       = "invalid_format"
+	ParseErrorOther        = "other"
+)
+
+// ParseError 同一类型的解析失败汇总
+type ParseError struct {
+	Class   string   `json:"class" description:"错误类型: unsupported_protocol/bad_cipher/missing_field/invalid_format/other"`
+	Count   uint32   `json:"count" description:"失败节点数"`
+	Samples []string `json:"samples" description:"示例错误信息"`
+}
+
+// ParseErrorReport 订阅的解析失败报告
+type ParseErrorReport struct {
+	ID      uint16       `json:"id" description:"订阅ID"`
+	Name    string       `json:"name" description:"订阅名称"`
+	LastRun time.Time    `json:"last_run" description:"上次运行时间"`
+	Errors  []ParseError `json:"errors"`
+}
+
+// Userinfo 订阅响应头 Subscription-Userinfo 及 profile-* 中的信息
+type Userinfo struct {
+	Upload         uint64 `json:"upload,omitempty" description:"已用上传流量(字节)"`
+	Download       uint64 `json:"download,omitempty" description:"已用下载流量(字节)"`
+	Total          uint64 `json:"total,omitempty" description:"总流量(字节)"`
+	Expire         int64  `json:"expire,omitempty" description:"到期时间(unix 秒)"`
+	Title          string `json:"title,omitempty" description:"订阅标题"`
+	UpdateInterval uint16 `json:"update_interval,omitempty" description:"建议更新间隔(小时)"`
+}
+
+type QuotaNotify struct {
+	ID     uint16 `json:"id"`
+	Name   string `json:"name"`
+	Msg    string `json:"msg"`
+	Used   string `json:"used"`
+	Total  string `json:"total"`
+	Expire string `json:"expire"`
 }
 
 type Request struct {
@@ -60,6 +140,18 @@ type Response struct {
 	UpdatedAt time.Time            `json:"updated_at" description:"更新时间"`
 }
 
+// Source 返回订阅来源, 未设置时根据链接推断
+func (c *Config) Source() string {
+	switch c.Type {
+	case SourceFile, SourceContent:
+		return c.Type
+	}
+	if strings.HasPrefix(c.Url, "file://") {
+		return SourceFile
+	}
+	return SourceUrl
+}
+
 func (c *Request) GenData(id uint16) Data {
 	configBytes, err := json.Marshal(c.Config)
 	if err != nil {
@@ -91,8 +183,28 @@ func (d *Data) GenResponse(status string, subInfo nodeModel.SimpleInfo) Response
 		Config:    config,
 		Status:    status,
 		Result:    result,
-		Info:      subInfo,
+		Info:      result.ScoreInfo(subInfo),
 		CreatedAt: d.CreatedAt,
 		UpdatedAt: d.UpdatedAt,
 	}
 }
+
+// UsedPercent 已用流量百分比, 未提供总流量时返回 0
+func (u *Userinfo) UsedPercent() uint8 {
+	if u == nil || u.Total == 0 {
+		return 0
+	}
+	used := u.Upload + u.Download
+	if used >= u.Total {
+		return 100
+	}
+	return uint8(used * 100 / u.Total)
+}
+
+// ExpireIn 距离到期的时长, 未提供到期时间时返回 false
+func (u *Userinfo) ExpireIn() (time.Duration, bool) {
+	if u == nil || u.Expire == 0 {
+		return 0, false
+	}
+	return time.Until(time.Unix(u.Expire, 0)), true
+}
@@ -5,52 +5,52 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"text/template"
 
 	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/core/subconv"
 	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
 	"github.com/bestruirui/bestsub/internal/models/share"
 	"github.com/bestruirui/bestsub/internal/utils"
 	"github.com/bestruirui/bestsub/internal/utils/country"
+	"github.com/bestruirui/bestsub/internal/utils/log"
 )
 
-func GenSubData(genConfigStr string) []byte {
+// GenSubData 生成目标格式的订阅内容, 同时返回目标格式无法表达而被跳过的节点
+func GenSubData(genConfigStr string) ([]byte, []subconv.Unsupported) {
 	var genConfig share.GenConfig
 	if err := json.Unmarshal([]byte(genConfigStr), &genConfig); err != nil {
-		return nil
+		return nil, nil
 	}
 	nodes := node.GetByFilter(genConfig.Filter)
-	var result bytes.Buffer
-	result.Write(nodeData)
-	tmpl, err := renameTemplate.Parse(genConfig.Rename)
+	tmpl, err := newRenameTemplate(genConfig.Rename)
 	if err != nil {
-		return nil
+		return nil, nil
 	}
+	proxies := make([]map[string]any, 0, len(*nodes))
+	metas := make(subMetas)
 	var newName bytes.Buffer
 	for i, node := range *nodes {
 		newName.Reset()
-		result.Write(dash)
-		subTags := op.GetSubTagsByID(context.Background(), node.Base.SubId)
-		simpleInfo := renameTmpl{
-			SpeedUp:       node.Info.SpeedUp.Average(),
-			SpeedDown:     node.Info.SpeedDown.Average(),
-			Delay:         uint32(node.Info.Delay.Average()),
-			Risk:          uint32(node.Info.Risk),
-			Count:         uint32(i + 1),
-			Country:       country.GetCountry(node.Info.Country),
-			IP:            utils.Uint32ToIP(node.Info.IP),
-			SubName:       op.GetSubNameByID(context.Background(), node.Base.SubId),
-			SubTags:       fmt.Sprintf("<%s>", strings.Join(subTags, "|")),
-			SubTagsOrigin: subTags,
+		tmpl.Execute(&newName, newRenameTmpl(i, &node, metas.get(node.Base.SubId)))
+		var proxy map[string]any
+		if err := json.Unmarshal(node.Base.Raw, &proxy); err != nil {
+			continue
 		}
-		tmpl.Execute(&newName, simpleInfo)
-		result.Write(rename(node.Base.Raw, newName.Bytes()))
-		result.Write(newLine)
+		proxy["name"] = newName.String()
+		proxies = append(proxies, proxy)
 	}
-	resultStr := subconv.ConvertData(result.String(), genConfig.Target)
-	return []byte(resultStr)
+	result, skipped := subconv.Convert(proxies, genConfig.Target)
+	if len(skipped) > 0 {
+		log.Warnf("share target %s cannot express %d nodes, first: %s", genConfig.Target, len(skipped), skipped[0])
+		for _, s := range skipped {
+			log.Debugf("share target %s skipped node %s", genConfig.Target, s)
+		}
+	}
+	return result, skipped
 }
 
 func GenNodeData(config string) []byte {
@@ -61,28 +61,16 @@ func GenNodeData(config string) []byte {
 	nodes := node.GetByFilter(genConfig.Filter)
 	var result bytes.Buffer
 	result.Write(nodeData)
-	tmpl, err := renameTemplate.Parse(genConfig.Rename)
+	tmpl, err := newRenameTemplate(genConfig.Rename)
 	if err != nil {
 		return nil
 	}
+	metas := make(subMetas)
 	var newName bytes.Buffer
 	for i, node := range *nodes {
 		newName.Reset()
 		result.Write(dash)
-		subTags := op.GetSubTagsByID(context.Background(), node.Base.SubId)
-		simpleInfo := renameTmpl{
-			SpeedUp:       node.Info.SpeedUp.Average(),
-			SpeedDown:     node.Info.SpeedDown.Average(),
-			Delay:         uint32(node.Info.Delay.Average()),
-			Risk:          uint32(node.Info.Risk),
-			Count:         uint32(i + 1),
-			Country:       country.GetCountry(node.Info.Country),
-			IP:            utils.Uint32ToIP(node.Info.IP),
-			SubName:       op.GetSubNameByID(context.Background(), node.Base.SubId),
-			SubTags:       fmt.Sprintf("<%s>", strings.Join(subTags, "|")),
-			SubTagsOrigin: subTags,
-		}
-		tmpl.Execute(&newName, simpleInfo)
+		tmpl.Execute(&newName, newRenameTmpl(i, &node, metas.get(node.Base.SubId)))
 		result.Write(rename(node.Base.Raw, newName.Bytes()))
 		result.Write(newLine)
 	}
@@ -119,9 +107,61 @@ type renameTmpl struct {
 	SubName       string
 	SubTags       string
 	SubTagsOrigin []string
+
+	Capabilities       string
+	CapabilitiesOrigin []string
+}
+
+// subMeta 订阅名称与标签, 单次生成内按订阅缓存, 避免每个节点重复查询和解析
+type subMeta struct {
+	name       string
+	tags       string
+	tagsOrigin []string
 }
 
-var renameTemplate = template.New("node").Funcs(template.FuncMap{
+type subMetas map[uint16]*subMeta
+
+func (m subMetas) get(subID uint16) *subMeta {
+	if meta, ok := m[subID]; ok {
+		return meta
+	}
+	tags := op.GetSubTagsByID(context.Background(), subID)
+	meta := &subMeta{
+		name:       op.GetSubNameByID(context.Background(), subID),
+		tags:       fmt.Sprintf("<%s>", strings.Join(tags, "|")),
+		tagsOrigin: tags,
+	}
+	m[subID] = meta
+	return meta
+}
+
+func newRenameTmpl(i int, node *nodeModel.Data, meta *subMeta) renameTmpl {
+	return renameTmpl{
+		SpeedUp:       node.Info.SpeedUp.Average(),
+		SpeedDown:     node.Info.SpeedDown.Average(),
+		Delay:         uint32(node.Info.Delay.Average()),
+		Risk:          uint32(node.Info.Risk),
+		Count:         uint32(i + 1),
+		Country:       country.GetCountry(node.Info.Country),
+		IP:            utils.Uint32ToIP(node.Info.IP),
+		SubName:       meta.name,
+		SubTags:       meta.tags,
+		SubTagsOrigin: meta.tagsOrigin,
+
+		Capabilities:       fmt.Sprintf("<%s>", strings.Join(node.Info.Capabilities, "|")),
+		CapabilitiesOrigin: node.Info.Capabilities,
+	}
+}
+
+// newRenameTemplate 每次生成分享时解析一个新的模板, 共享的模板并发 Parse 会产生竞争
+func newRenameTemplate(text string) (*template.Template, error) {
+	return template.New("node").Funcs(renameFuncs).Parse(text)
+}
+
+var renameFuncs = template.FuncMap{
+	"has": func(list []string, s string) bool {
+		return slices.Contains(list, s)
+	},
 	"add": func(x, y uint32) uint32 {
 		return x + y
 	},
@@ -140,4 +180,4 @@ var renameTemplate = template.New("node").Funcs(template.FuncMap{
 		}
 		return x % y
 	},
-})
+}
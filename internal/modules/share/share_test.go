@@ -0,0 +1,170 @@
+package share
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/bestruirui/bestsub/internal/core/check/checker"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/core/task"
+	"github.com/bestruirui/bestsub/internal/database"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/models/share"
+	"github.com/bestruirui/bestsub/internal/utils/log"
+)
+
+const testPoolSize = 200
+
+// TestMain 使用临时 sqlite 数据库, 节点池从数据库恢复 direct 节点, 检测不依赖外部网络
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bestsub-share")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	database.Initialize("sqlite", filepath.Join(dir, "bestsub.db"))
+	task.Init(32)
+
+	nodes := make([]nodeModel.Data, testPoolSize)
+	for i := range nodes {
+		info := &nodeModel.Info{Country: []string{"US", "JP", "HK"}[i%3]}
+		info.Delay.Update(uint16(100 + i))
+		nodes[i] = nodeModel.Data{
+			Base: nodeModel.Base{
+				Raw:       fmt.Appendf(nil, `{"name":"n%d","type":"direct"}`, i),
+				SubId:     uint16(i%4 + 1),
+				UniqueKey: uint64(i + 1),
+			},
+			Info: info,
+		}
+	}
+	if err := op.SaveNodes(context.Background(), nodes); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	node.InitNodePool(testPoolSize)
+
+	code := m.Run()
+	task.Release()
+	database.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// TestConcurrentCheckAndShare 检测任务、分享生成与查询并发执行, 需配合 go test -race
+func TestConcurrentCheckAndShare(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	probes, _ := json.Marshal([]map[string]string{
+		{"capability": "web", "url": srv.URL},
+		{"capability": "api", "url": srv.URL, "json_path": "$.ok"},
+	})
+	probe := &checker.Probe{Thread: 16, Timeout: 5, Probes: string(probes)}
+
+	genConfig, _ := json.Marshal(share.GenConfig{
+		Filter: nodeModel.Filter{Country: []string{"US", "JP"}},
+		Rename: `{{.Count}}-{{.Country.Emoji}}{{if has .CapabilitiesOrigin "web"}}-web{{end}}`,
+		Target: "mihomo",
+	})
+
+	const rounds = 5
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				fn(i)
+			}
+		}()
+	}
+
+	// 检测任务
+	run(func(int) {
+		probe.Run(context.Background(), log.GetDefaultLogger(), nil)
+		node.Rescore()
+		node.RefreshInfo()
+		node.SyncInfo()
+	})
+	run(func(i int) {
+		for key := uint64(1); key <= testPoolSize; key++ {
+			node.UpdateInfo(key, func(info *nodeModel.Info) {
+				node.RecordAlive(info, (int(key)+i)%2 == 0)
+				info.Country = []string{"US", "JP", "HK", "SG"}[(int(key)+i)%4]
+				info.Delay.Update(uint16(key))
+			})
+		}
+	})
+	// 分享
+	for range 4 {
+		run(func(int) {
+			if data, _ := GenSubData(string(genConfig)); len(data) == 0 {
+				t.Error("empty sub data")
+			}
+			if len(GenNodeData(string(genConfig))) == 0 {
+				t.Error("empty node data")
+			}
+		})
+	}
+	// 查询
+	run(func(int) {
+		node.GetByFilter(nodeModel.Filter{AliveStatus: nodeModel.Alive})
+		node.GetBySubId([]uint16{1, 2})
+		node.Query(nodeModel.Query{Sort: "delay", Page: 1, PageSize: 20})
+		node.GetAll()
+		node.GetSubInfo(1)
+	})
+	wg.Wait()
+
+	nodes := node.GetAll()
+	if len(nodes) != testPoolSize {
+		t.Fatalf("pool has %d nodes, want %d", len(nodes), testPoolSize)
+	}
+	for _, n := range nodes {
+		if !n.Info.HasCapability("web") || !n.Info.HasCapability("api") {
+			t.Fatalf("node %d capabilities %v, want web and api", n.UniqueKey, n.Info.Capabilities)
+		}
+	}
+}
+
+// TestProbeReplacesCapabilities 删除探测规则后, 再次检测应清除对应的能力
+func TestProbeReplacesCapabilities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+	run := func(rules ...map[string]string) {
+		probes, _ := json.Marshal(rules)
+		probe := &checker.Probe{Thread: 16, Timeout: 5, Probes: string(probes)}
+		probe.Run(context.Background(), log.GetDefaultLogger(), []uint16{1})
+	}
+	run(
+		map[string]string{"capability": "web", "url": srv.URL},
+		map[string]string{"capability": "old", "url": srv.URL},
+		map[string]string{"capability": "fail", "url": srv.URL + "/fail"},
+	)
+	run(map[string]string{"capability": "web", "url": srv.URL})
+
+	for _, n := range *node.GetBySubId([]uint16{1}) {
+		if !slices.Equal(n.Info.Capabilities, []string{"web"}) {
+			t.Fatalf("node %d capabilities %v, want [web]", n.UniqueKey, n.Info.Capabilities)
+		}
+	}
+	if nodes := node.GetByFilter(nodeModel.Filter{Capability: []string{"old"}}); len(*nodes) != 0 {
+		t.Fatalf("%d nodes still indexed with removed capability", len(*nodes))
+	}
+}
@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bestruirui/bestsub/internal/core/fetch"
+	"github.com/bestruirui/bestsub/internal/core/node"
+	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
+	"github.com/bestruirui/bestsub/internal/server/middleware"
+	"github.com/bestruirui/bestsub/internal/server/resp"
+	"github.com/bestruirui/bestsub/internal/server/router"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	router.NewGroupRouter("/api/v1/node").
+		Use(middleware.Auth()).
+		AddRoute(
+			router.NewRoute("", router.GET).
+				Handle(getNodes),
+		).
+		AddRoute(
+			router.NewRoute("", router.POST).
+				Handle(addNode),
+		).
+		AddRoute(
+			router.NewRoute("/mark", router.GET).
+				Handle(getNodeMarks),
+		).
+		AddRoute(
+			router.NewRoute("/label", router.GET).
+				Handle(getNodeLabels),
+		).
+		AddRoute(
+			router.NewRoute("/:key", router.GET).
+				Handle(getNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key", router.DELETE).
+				Handle(deleteNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key/pin", router.POST).
+				Handle(pinNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key/pin", router.DELETE).
+				Handle(unpinNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key/blacklist", router.POST).
+				Handle(blacklistNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key/blacklist", router.DELETE).
+				Handle(unblacklistNode),
+		).
+		AddRoute(
+			router.NewRoute("/:key/label", router.POST).
+				Handle(addNodeLabel),
+		).
+		AddRoute(
+			router.NewRoute("/:key/label/:label", router.DELETE).
+				Handle(deleteNodeLabel),
+		)
+}
+
+// getNodes 获取节点列表
+// @Summary 获取节点列表
+// @Description 按过滤条件、搜索关键字筛选节点池, 支持排序与分页
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param query query nodeModel.Query false "查询参数"
+// @Success 200 {object} resp.ResponseStruct{data=nodeModel.Page} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node [get]
+func getNodes(c *gin.Context) {
+	var query nodeModel.Query
+	if err := c.ShouldBindQuery(&query); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	total, nodes := node.Query(query)
+	page := nodeModel.Page{
+		Total: total,
+		List:  make([]nodeModel.Item, len(nodes)),
+	}
+	for i := range nodes {
+		page.List[i] = nodes[i].GenItem(op.GetSubNameByID(c.Request.Context(), nodes[i].SubId))
+		page.List[i].Pinned = node.IsPinned(nodes[i].UniqueKey)
+	}
+	resp.Success(c, page)
+}
+
+// getNode 获取节点详情
+// @Summary 获取节点详情
+// @Description 获取节点配置、完整的延迟与速度记录以及解析后的检测状态
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct{data=nodeModel.Detail} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 404 {object} resp.ResponseStruct "节点不存在"
+// @Router /api/v1/node/{key} [get]
+func getNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	n, ok := node.GetByKey(key)
+	if !ok {
+		resp.Error(c, http.StatusNotFound, "node not found")
+		return
+	}
+	detail := n.GenDetail(op.GetSubNameByID(c.Request.Context(), n.SubId), node.OfferedBy(key))
+	detail.Pinned = node.IsPinned(key)
+	resp.Success(c, detail)
+}
+
+// addNode 手动添加节点
+// @Summary 手动添加节点
+// @Description 提交分享链接或 YAML 片段, 解析后进入测试队列, 测试通过后加入节点池
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body nodeModel.AddRequest true "节点内容"
+// @Success 200 {object} resp.ResponseStruct{data=nodeModel.AddResponse} "提交成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node [post]
+func addNode(c *gin.Context) {
+	var req nodeModel.AddRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	result := fetch.AddManual([]byte(req.Content))
+	if result.Parsed == 0 {
+		resp.Error(c, http.StatusBadRequest, "no valid node found")
+		return
+	}
+	resp.Success(c, result)
+}
+
+// deleteNode 删除节点
+// @Summary 删除节点
+// @Description 将单个节点移出节点池, 节点仍可能被订阅再次加入, 如需永久排除请使用黑名单
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct "删除成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 404 {object} resp.ResponseStruct "节点不存在"
+// @Router /api/v1/node/{key} [delete]
+func deleteNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if !node.Delete(key) {
+		resp.Error(c, http.StatusNotFound, "node not found")
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// getNodeMarks 获取节点标记
+// @Summary 获取节点标记
+// @Description 获取所有固定与黑名单中的节点
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]nodeModel.Mark} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/node/mark [get]
+func getNodeMarks(c *gin.Context) {
+	marks, err := op.GetNodeMarkList(c.Request.Context())
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, marks)
+}
+
+// pinNode 固定节点
+// @Summary 固定节点
+// @Description 固定节点池中的节点, 固定的节点不会因排名或健康检测被淘汰
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct "固定成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误或固定节点已占满节点池"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 404 {object} resp.ResponseStruct "节点不存在"
+// @Router /api/v1/node/{key}/pin [post]
+func pinNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if _, ok := node.GetByKey(key); !ok {
+		resp.Error(c, http.StatusNotFound, "node not found")
+		return
+	}
+	if err := node.Pin(c.Request.Context(), key); err != nil {
+		if errors.Is(err, node.ErrPinFull) {
+			resp.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// unpinNode 取消固定节点
+// @Summary 取消固定节点
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct "取消成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node/{key}/pin [delete]
+func unpinNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if err := node.Unpin(c.Request.Context(), key); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// blacklistNode 拉黑节点
+// @Summary 拉黑节点
+// @Description 将节点移出节点池并加入黑名单, 之后任何订阅都不会再将其加入节点池
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct "拉黑成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node/{key}/blacklist [post]
+func blacklistNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if err := node.Blacklist(c.Request.Context(), key); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// unblacklistNode 移出黑名单
+// @Summary 移出黑名单
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Success 200 {object} resp.ResponseStruct "移出成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node/{key}/blacklist [delete]
+func unblacklistNode(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if err := node.Unblacklist(c.Request.Context(), key); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// getNodeLabels 获取节点标签
+// @Summary 获取节点标签
+// @Description 获取节点池中出现的全部标签及对应的节点数, 包括手动添加与规则添加的标签
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]nodeModel.LabelCount} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/node/label [get]
+func getNodeLabels(c *gin.Context) {
+	resp.Success(c, node.GetLabels())
+}
+
+// addNodeLabel 添加节点标签
+// @Summary 添加节点标签
+// @Description 为节点手动添加标签, 节点被移出节点池后标签仍会保留
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Param request body nodeModel.LabelRequest true "标签"
+// @Success 200 {object} resp.ResponseStruct "添加成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/node/{key}/label [post]
+func addNodeLabel(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	var req nodeModel.LabelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if err := node.AddLabels(c.Request.Context(), key, req.Labels); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
+
+// deleteNodeLabel 删除节点标签
+// @Summary 删除节点标签
+// @Description 删除手动添加的标签, 规则添加的标签需要修改标签规则
+// @Tags 节点
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "节点唯一键"
+// @Param label path string true "标签"
+// @Success 200 {object} resp.ResponseStruct "删除成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/node/{key}/label/{label} [delete]
+func deleteNodeLabel(c *gin.Context) {
+	key, err := strconv.ParseUint(c.Param("key"), 10, 64)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	if err := node.RemoveLabel(c.Request.Context(), key, c.Param("label")); err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, nil)
+}
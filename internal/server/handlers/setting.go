@@ -2,9 +2,15 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/bestruirui/bestsub/internal/core/node"
 	"github.com/bestruirui/bestsub/internal/database/op"
+	nodeModel "github.com/bestruirui/bestsub/internal/models/node"
 	"github.com/bestruirui/bestsub/internal/models/setting"
 	"github.com/bestruirui/bestsub/internal/server/middleware"
 	"github.com/bestruirui/bestsub/internal/server/resp"
@@ -51,13 +57,13 @@ func getSetting(c *gin.Context) {
 
 // updateSetting 更新配置项
 // @Summary 更新配置项
-// @Description 根据请求数据中的ID批量更新配置项的值和描述
+// @Description 根据请求数据中的ID批量更新配置项的值和描述, 修改节点池容量或去重方式时立即调整节点池并返回调整结果
 // @Tags 配置
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body []setting.Setting  true "更新配置项请求"
-// @Success 200 {object} resp.ResponseStruct "更新成功"
+// @Success 200 {object} resp.ResponseStruct{data=nodeModel.PoolUpdate} "更新成功"
 // @Failure 400 {object} resp.ResponseStruct "请求参数错误"
 // @Failure 401 {object} resp.ResponseStruct "未授权"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
@@ -68,6 +74,43 @@ func updateSetting(c *gin.Context) {
 		resp.ErrorBadRequest(c)
 		return
 	}
+	poolSize := -1
+	rekey, relabel, refilter := false, false, false
+	for _, item := range req {
+		switch item.Key {
+		case setting.NODE_POOL_SIZE:
+			size, err := strconv.Atoi(item.Value)
+			if err != nil || size <= 0 {
+				resp.Error(c, http.StatusBadRequest, "node pool size must be a positive integer")
+				return
+			}
+			if pinned := node.PinnedCount(); size < pinned {
+				resp.Error(c, http.StatusBadRequest, fmt.Sprintf("node pool size must not be less than the %d pinned nodes", pinned))
+				return
+			}
+			poolSize = size
+		case setting.NODE_POOL_POLICY:
+			if !slices.Contains(node.Policies(), item.Value) {
+				resp.Error(c, http.StatusBadRequest, fmt.Sprintf("unknown node pool policy %q, available: %s", item.Value, strings.Join(node.Policies(), ", ")))
+				return
+			}
+		case setting.NODE_DEDUPE_KEY:
+			if err := node.CheckDedupeKey(item.Value); err != nil {
+				resp.Error(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			rekey = item.Value != op.GetSettingStr(setting.NODE_DEDUPE_KEY)
+		case setting.NODE_FIELD_FILTER, setting.NODE_PROTOCOL_FILTER_ENABLE,
+			setting.NODE_PROTOCOL_FILTER_MODE, setting.NODE_PROTOCOL_FILTER:
+			refilter = refilter || item.Value != op.GetSettingStr(item.Key)
+		case setting.NODE_LABEL_RULES:
+			if err := node.CheckLabelRules(item.Value); err != nil {
+				resp.Error(c, http.StatusBadRequest, err.Error())
+				return
+			}
+			relabel = item.Value != op.GetSettingStr(setting.NODE_LABEL_RULES)
+		}
+	}
 
 	err := op.UpdateSetting(context.Background(), &req)
 	if err != nil {
@@ -76,5 +119,26 @@ func updateSetting(c *gin.Context) {
 		return
 	}
 
-	resp.Success(c, nil)
+	if relabel {
+		node.Relabel()
+	}
+	if refilter {
+		if err := op.ClearSubResultCache(context.Background()); err != nil {
+			log.Warnf("clear sub result cache failed: %v", err)
+		}
+	}
+	if poolSize < 0 && !rekey {
+		resp.Success(c, nil)
+		return
+	}
+	var update nodeModel.PoolUpdate
+	if poolSize > 0 {
+		result := node.Resize(poolSize)
+		update.Resize = &result
+	}
+	if rekey {
+		result := node.Rekey()
+		update.Rekey = &result
+	}
+	resp.Success(c, update)
 }
@@ -198,6 +198,7 @@ func getShareNodeContent(c *gin.Context) {
 // @Produce plain
 // @Param token path string true "分享token"
 // @Success 200 {string} string "获取成功，内容为yaml/plain格式"
+// @Header 200 {int} X-Skipped-Nodes "目标格式无法表达而被跳过的节点数量"
 // @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
 // @Router /api/v1/share/sub/{token} [get]
 func getShareSubContent(c *gin.Context) {
@@ -224,5 +225,7 @@ func getShareSubContent(c *gin.Context) {
 		return
 	}
 	op.UpdateShareAccessCount(c.Request.Context(), shareData.ID)
-	c.Data(http.StatusOK, "text/plain; charset=utf-8", share.GenSubData(shareData.Gen))
+	data, skipped := share.GenSubData(shareData.Gen)
+	c.Header("X-Skipped-Nodes", strconv.Itoa(len(skipped)))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", data)
 }
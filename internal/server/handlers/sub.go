@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/bestruirui/bestsub/internal/server/resp"
 	"github.com/bestruirui/bestsub/internal/server/router"
 	"github.com/bestruirui/bestsub/internal/utils/log"
+	"github.com/bestruirui/bestsub/internal/utils/ua"
 	"github.com/gin-gonic/gin"
 )
 
@@ -41,6 +43,22 @@ func init() {
 		AddRoute(
 			router.NewRoute("/batch", router.POST).
 				Handle(batchCreateSub),
+		).
+		AddRoute(
+			router.NewRoute("/user-agent", router.GET).
+				Handle(getSubUserAgents),
+		).
+		AddRoute(
+			router.NewRoute("/:id/history", router.GET).
+				Handle(getSubHistory),
+		).
+		AddRoute(
+			router.NewRoute("/overlap", router.GET).
+				Handle(getSubOverlap),
+		).
+		AddRoute(
+			router.NewRoute("/parse-error", router.GET).
+				Handle(getSubParseErrors),
 		)
 }
 
@@ -154,6 +172,7 @@ func updateSub(c *gin.Context) {
 		resp.Error(c, http.StatusInternalServerError, err.Error())
 		return
 	}
+	node.Relabel()
 	respData := subData.GenResponse(cron.FetchStatus(subData.ID), node.GetSubInfo(subData.ID))
 	resp.Success(c, respData)
 }
@@ -188,6 +207,7 @@ func deleteSub(c *gin.Context) {
 		return
 	}
 	node.DeleteBySubId(uint16(id))
+	node.DeleteOffers(uint16(id))
 	resp.Success(c, nil)
 }
 
@@ -262,3 +282,101 @@ func batchCreateSub(c *gin.Context) {
 	}
 	resp.Success(c, respData)
 }
+
+// getSubUserAgents 获取 User-Agent 预设
+// @Summary 获取 User-Agent 预设
+// @Description 获取订阅请求可用的 User-Agent 预设名称
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]string} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/sub/user-agent [get]
+func getSubUserAgents(c *gin.Context) {
+	resp.Success(c, ua.Presets())
+}
+
+// getSubHistory 获取订阅拉取记录
+// @Summary 获取订阅拉取记录
+// @Description 获取订阅最近的拉取记录以及保留期内的成功率、平均节点数等统计
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅链接ID"
+// @Param limit query int false "返回的记录条数, 默认 100"
+// @Success 200 {object} resp.ResponseStruct{data=sub.HistoryResponse} "获取成功"
+// @Failure 400 {object} resp.ResponseStruct "请求参数错误"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/{id}/history [get]
+func getSubHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 16)
+	if err != nil {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		resp.ErrorBadRequest(c)
+		return
+	}
+	history, err := op.GetSubHistory(c.Request.Context(), uint16(id), limit)
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Success(c, history)
+}
+
+// getSubOverlap 获取订阅重复情况
+// @Summary 获取订阅重复情况
+// @Description 根据各订阅最近一次提供的节点, 统计订阅之间共有的节点数以及各订阅独有的节点数
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=nodeModel.Overlap} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Router /api/v1/sub/overlap [get]
+func getSubOverlap(c *gin.Context) {
+	overlap := node.GetOverlap()
+	for i := range overlap.Subs {
+		overlap.Subs[i].Name = op.GetSubNameByID(c.Request.Context(), overlap.Subs[i].ID)
+	}
+	resp.Success(c, overlap)
+}
+
+// getSubParseErrors 获取订阅解析失败报告
+// @Summary 获取订阅解析失败报告
+// @Description 获取各订阅上次拉取中解析或校验失败的节点, 按错误类型汇总, 仅返回存在失败的订阅
+// @Tags 订阅
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} resp.ResponseStruct{data=[]sub.ParseErrorReport} "获取成功"
+// @Failure 401 {object} resp.ResponseStruct "未授权"
+// @Failure 500 {object} resp.ResponseStruct "服务器内部错误"
+// @Router /api/v1/sub/parse-error [get]
+func getSubParseErrors(c *gin.Context) {
+	subList, err := op.GetSubList(c.Request.Context())
+	if err != nil {
+		resp.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	reports := make([]sub.ParseErrorReport, 0)
+	for _, s := range subList {
+		var result sub.Result
+		if err := json.Unmarshal([]byte(s.Result), &result); err != nil || len(result.ParseErrors) == 0 {
+			continue
+		}
+		reports = append(reports, sub.ParseErrorReport{
+			ID:      s.ID,
+			Name:    s.Name,
+			LastRun: result.LastRun,
+			Errors:  result.ParseErrors,
+		})
+	}
+	resp.Success(c, reports)
+}
@@ -5,6 +5,7 @@ type Integer interface {
 		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
 }
 
+// Queue 固定容量的环形队列, 非并发安全, 由调用方加锁
 type Queue[T Integer] struct {
 	Data []T
 	Ptr  int
@@ -52,6 +53,13 @@ func (q *Queue[T]) GetAll() []T {
 	return result
 }
 
+// Clone 深拷贝队列, 保留原容量
+func (q *Queue[T]) Clone() Queue[T] {
+	data := make([]T, len(q.Data), cap(q.Data))
+	copy(data, q.Data)
+	return Queue[T]{Data: data, Ptr: q.Ptr, Full: q.Full}
+}
+
 func (q *Queue[T]) Clear() {
 	q.Data = q.Data[:0]
 	q.Ptr = 0
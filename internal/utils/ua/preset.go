@@ -0,0 +1,41 @@
+package ua
+
+import "strings"
+
+// presets 常见代理客户端的 User-Agent, 部分面板会据此返回不同格式的订阅
+var presets = map[string]string{
+	"clash":        "clash/1.18.0",
+	"clash-meta":   "clash.meta/v1.19.26",
+	"mihomo":       "mihomo/v1.19.26",
+	"stash":        "Stash/2.7.2 Clash/1.11.0",
+	"v2rayn":       "v2rayN/7.10.5",
+	"v2rayng":      "v2rayNG/1.9.40",
+	"sing-box":     "sing-box 1.11.0",
+	"shadowrocket": "Shadowrocket/2070 CFNetwork/1494.0.7 Darwin/23.4.0",
+	"quantumultx":  "Quantumult%20X/1.5.2",
+	"surge":        "Surge iOS/3374",
+	"loon":         "Loon/3.2.4",
+}
+
+// Preset 返回预设名称对应的 User-Agent, browser 为随机浏览器 UA
+// 非预设名称原样返回, 作为自定义 User-Agent 使用
+func Preset(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "browser" {
+		return Random()
+	}
+	if v, ok := presets[key]; ok {
+		return v
+	}
+	return name
+}
+
+// Presets 返回所有预设名称
+func Presets() []string {
+	names := make([]string, 0, len(presets)+1)
+	names = append(names, "browser")
+	for name := range presets {
+		names = append(names, name)
+	}
+	return names
+}